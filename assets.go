@@ -0,0 +1,27 @@
+// Package assets contiene las plantillas, los archivos estáticos y las
+// traducciones de la aplicación, embebidos en el binario.
+package assets
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed templates static locales
+var files embed.FS
+
+// Sistemas de archivos embebidos, con la raíz en cada directorio
+var (
+	Templates = mustSub("templates")
+	Static    = mustSub("static")
+	Locales   = mustSub("locales")
+)
+
+// mustSub devuelve el subdirectorio dir del sistema de archivos embebido
+func mustSub(dir string) fs.FS {
+	sub, err := fs.Sub(files, dir)
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
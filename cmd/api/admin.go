@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/i18n"
+	"github.com/prosales/go-api-movies/pkg/models"
+)
+
+// Límites de los formularios de administración
+const (
+	maxAdminFormBytes = 64 << 10
+	maxWarmQueries    = 200
+	maxImportBytes    = 64 << 20
+)
+
+// Avisos que se muestran en /admin tras una acción, por su valor en ?notice=
+var adminNotices = map[string]string{
+	"evicted":  "admin_notice_evicted",
+	"missing":  "admin_notice_missing",
+	"flushed":  "admin_notice_flushed",
+	"imported": "admin_notice_imported",
+}
+
+// requireAdmin protege el área de administración con autenticación básica.
+// Los formularios solo se aceptan desde el mismo origen, porque el navegador
+// reenvía las credenciales en peticiones iniciadas por otros sitios.
+func (app *application) requireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, ok := r.BasicAuth()
+		if !ok || !app.adminCredentials(user, password) {
+			w.Header().Set("WWW-Authenticate", `Basic realm="admin", charset="UTF-8"`)
+			app.renderError(w, r, http.StatusUnauthorized, "error_unauthorized")
+			return
+		}
+		if r.Method == http.MethodPost && !sameOrigin(r) {
+			app.renderError(w, r, http.StatusForbidden, "error_forbidden")
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// adminCredentials compara las credenciales en tiempo constante
+func (app *application) adminCredentials(user, password string) bool {
+	userHash := sha256.Sum256([]byte(user))
+	wantUserHash := sha256.Sum256([]byte(app.adminUser))
+	passwordHash := sha256.Sum256([]byte(password))
+	wantPasswordHash := sha256.Sum256([]byte(app.adminPassword))
+
+	userOK := subtle.ConstantTimeCompare(userHash[:], wantUserHash[:]) == 1
+	passwordOK := subtle.ConstantTimeCompare(passwordHash[:], wantPasswordHash[:]) == 1
+	return userOK && passwordOK && app.adminPassword != ""
+}
+
+// sameOrigin indica si la petición procede de una página del propio sitio. Los
+// clientes que no son navegadores no envían Sec-Fetch-Site ni Origin.
+func sameOrigin(r *http.Request) bool {
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" {
+		return site == "same-origin" || site == "none"
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		return err == nil && u.Host == r.Host
+	}
+	return true
+}
+
+// Handler del listado de la caché: /admin?q=...
+func (app *application) adminHandler(w http.ResponseWriter, r *http.Request) {
+	lang := app.getLangFromRequest(r)
+	query := r.URL.Query().Get("q")
+
+	stats := app.movieModel.CacheStats()
+	data := &viewData{
+		Query:        query,
+		Lang:         lang,
+		CacheStats:   &stats,
+		CacheEntries: app.movieModel.CacheEntries(query),
+	}
+	app.addWarmStatus(data)
+	if key, ok := adminNotices[r.URL.Query().Get("notice")]; ok {
+		data.Notice = app.translator.T(lang, key)
+		if n, err := strconv.Atoi(r.URL.Query().Get("n")); err == nil {
+			data.Notice = app.translator.Tn(lang, key, n, nil)
+		}
+		if skipped, err := strconv.Atoi(r.URL.Query().Get("skipped")); err == nil {
+			data.Notice += " (" + app.translator.Tn(lang, "admin_skipped", skipped, nil) + ")"
+		}
+	}
+	app.render(w, r, "admin.html", data)
+}
+
+// Handler para eliminar una película de la caché
+func (app *application) adminEvictHandler(w http.ResponseWriter, r *http.Request) {
+	notice := "missing"
+	if app.movieModel.Evict(r.PostFormValue("key")) {
+		notice = "evicted"
+	}
+	http.Redirect(w, r, "/admin?notice="+notice, http.StatusSeeOther)
+}
+
+// Handler para vaciar la caché
+func (app *application) adminFlushHandler(w http.ResponseWriter, r *http.Request) {
+	n := app.movieModel.Flush()
+	http.Redirect(w, r, "/admin?notice=flushed&n="+strconv.Itoa(n), http.StatusSeeOther)
+}
+
+// Handler para precargar películas, una por línea, por título o ID de IMDb. La
+// precarga sigue en segundo plano con la concurrencia y el ritmo de --warm-*,
+// así que responde 202 y el progreso se consulta en /admin o en GET /admin/warm.
+func (app *application) adminWarmHandler(w http.ResponseWriter, r *http.Request) {
+	lang := app.getLangFromRequest(r)
+	r.Body = http.MaxBytesReader(w, r.Body, maxAdminFormBytes)
+
+	var queries []string
+	for _, line := range strings.Split(r.PostFormValue("titles"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			queries = append(queries, line)
+		}
+	}
+
+	data := &viewData{Lang: lang}
+	status := http.StatusOK
+	switch {
+	case len(queries) > maxWarmQueries:
+		data.Error = app.translator.Tf(lang, "admin_warm_too_many", i18n.Args{"max": maxWarmQueries})
+		status = http.StatusBadRequest
+	case len(queries) > 0:
+		if app.startAdminWarm(queries) {
+			status = http.StatusAccepted
+		} else {
+			data.Error = app.translator.T(lang, "admin_warm_busy")
+			status = http.StatusConflict
+		}
+	}
+	app.addWarmStatus(data)
+	data.CacheEntries = app.movieModel.CacheEntries("")
+	app.renderStatus(w, r, status, "admin.html", data)
+}
+
+// Handler con el progreso de la última precarga lanzada desde /admin, en JSON
+func (app *application) adminWarmStatusHandler(w http.ResponseWriter, r *http.Request) {
+	app.adminWarmMu.Lock()
+	wu := app.adminWarm
+	app.adminWarmMu.Unlock()
+
+	if wu == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no hay ninguna precarga"})
+		return
+	}
+	writeJSON(w, http.StatusOK, wu.snapshot())
+}
+
+// startAdminWarm lanza una precarga en segundo plano, salvo que ya haya otra en
+// curso. Sigue aunque termine la petición, hasta que se apague el servidor.
+func (app *application) startAdminWarm(queries []string) bool {
+	app.adminWarmMu.Lock()
+	defer app.adminWarmMu.Unlock()
+	if app.adminWarm != nil {
+		if _, done := app.adminWarm.finished(); !done {
+			return false
+		}
+	}
+
+	ctx := app.baseCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	app.adminWarm = newWarmup(len(queries))
+	go runWarmup(ctx, app.adminWarm, app.movieModel, queries, app.warmOptions)
+	return true
+}
+
+// addWarmStatus añade a data el progreso de la última precarga de /admin y, si
+// terminó, sus resultados
+func (app *application) addWarmStatus(data *viewData) {
+	app.adminWarmMu.Lock()
+	wu := app.adminWarm
+	app.adminWarmMu.Unlock()
+	if wu == nil {
+		return
+	}
+
+	status := wu.snapshot()
+	data.WarmStatus = &status
+	data.WarmResults, _ = wu.finished()
+}
+
+// Handler para descargar una instantánea de la caché en JSON lines
+func (app *application) adminExportHandler(w http.ResponseWriter, r *http.Request) {
+	filename := "movies-cache-" + time.Now().UTC().Format("20060102-150405") + ".jsonl"
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	n, err := app.movieModel.Export(w)
+	if err != nil {
+		// La respuesta ya está empezada: solo queda registrarlo
+		slog.ErrorContext(r.Context(), "cache export failed", "error", err)
+		return
+	}
+	slog.InfoContext(r.Context(), "cache exported", "movies", n)
+}
+
+// Handler para importar una instantánea. Acepta el formulario de /admin, con el
+// archivo en "snapshot", o la instantánea directamente en el cuerpo, en cuyo
+// caso responde en JSON. La política se indica en "policy" (newer por defecto).
+func (app *application) adminImportHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	fromForm := strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data")
+
+	var snapshot io.Reader = r.Body
+	if fromForm {
+		file, _, err := r.FormFile("snapshot")
+		if err != nil {
+			app.renderAdminError(w, r, err)
+			return
+		}
+		defer file.Close()
+		snapshot = file
+	}
+
+	// Fuera de un formulario el cuerpo es la instantánea: r.FormValue lo
+	// consumiría si llega como application/x-www-form-urlencoded
+	policyName := r.URL.Query().Get("policy")
+	if fromForm {
+		policyName = r.FormValue("policy")
+	}
+	if policyName == "" {
+		policyName = string(models.ImportNewer)
+	}
+	policy, err := models.ParseImportPolicy(policyName)
+	if err == nil {
+		var stats models.ImportStats
+		stats, err = app.movieModel.Import(snapshot, policy)
+		if err == nil {
+			slog.InfoContext(r.Context(), "cache imported", "policy", policy, "imported", stats.Imported, "skipped", stats.Skipped)
+			if fromForm {
+				http.Redirect(w, r, "/admin?notice=imported&n="+strconv.Itoa(stats.Imported)+"&skipped="+strconv.Itoa(stats.Skipped), http.StatusSeeOther)
+				return
+			}
+			writeJSON(w, http.StatusOK, stats)
+			return
+		}
+	}
+
+	if fromForm {
+		app.renderAdminError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+}
+
+// renderAdminError muestra la página de administración con un error
+func (app *application) renderAdminError(w http.ResponseWriter, r *http.Request, err error) {
+	app.renderStatus(w, r, http.StatusBadRequest, "admin.html", &viewData{
+		Error:        err.Error(),
+		CacheEntries: app.movieModel.CacheEntries(""),
+	})
+}
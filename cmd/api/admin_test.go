@@ -0,0 +1,403 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/i18n"
+	"github.com/prosales/go-api-movies/pkg/models"
+	"github.com/prosales/go-api-movies/pkg/omdb"
+)
+
+// newAdminApp crea una aplicación con el área de administración activada, las
+// plantillas y traducciones reales y un modelo mock
+func newAdminApp(t *testing.T, model *MockMovieModel) http.Handler {
+	t.Helper()
+
+	templates, err := loadTemplates(os.DirFS("../../templates"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	translator, err := i18n.NewTranslator(os.DirFS("../../locales"), "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &application{
+		movieModel:    model,
+		templates:     templates,
+		translator:    translator,
+		defaultLang:   "es",
+		adminUser:     "admin",
+		adminPassword: "secret",
+	}
+	return app.routes(os.DirFS("../../static"))
+}
+
+// adminRequest crea una petición autenticada al área de administración
+func adminRequest(method, target string, form url.Values) *http.Request {
+	var req *http.Request
+	if form != nil {
+		req = httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	} else {
+		req = httptest.NewRequest(method, target, nil)
+	}
+	req.SetBasicAuth("admin", "secret")
+	return req
+}
+
+// Test para requireAdmin: credenciales y origen de los formularios
+func TestAdmin_Auth(t *testing.T) {
+	handler := newAdminApp(t, &MockMovieModel{
+		FlushFunc: func() int {
+			t.Error("Flush should not be called")
+			return 0
+		},
+	})
+
+	req := httptest.NewRequest("GET", "/admin", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d without credentials, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "Basic") {
+		t.Errorf("Expected Basic challenge, got %q", w.Header().Get("WWW-Authenticate"))
+	}
+
+	req.SetBasicAuth("admin", "wrong")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status code %d with a wrong password, got %d", http.StatusUnauthorized, w.Code)
+	}
+
+	// Un formulario enviado desde otro sitio se rechaza aunque lleve credenciales
+	req = adminRequest("POST", "/admin/flush", url.Values{})
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status code %d for a cross-site form, got %d", http.StatusForbidden, w.Code)
+	}
+}
+
+// Test para el área de administración desactivada sin contraseña
+func TestAdmin_Disabled(t *testing.T) {
+	app := &application{
+		translator:  &MockTranslator{TFunc: func(lang, key string) string { return key }},
+		defaultLang: "es",
+	}
+	handler := app.routes(os.DirFS("../../static"))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, adminRequest("GET", "/admin", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// Test para adminHandler: lista y filtra las entradas de la caché
+func TestAdminHandler(t *testing.T) {
+	var gotQuery string
+	handler := newAdminApp(t, &MockMovieModel{
+		CacheEntriesFunc: func(query string) []models.CacheEntry {
+			gotQuery = query
+			return []models.CacheEntry{{
+				Key:      "id:tt0076759",
+				Title:    "Star Wars",
+				ImdbID:   "tt0076759",
+				Aliases:  []string{"star wars"},
+				CachedAt: time.Now(),
+				Hits:     42,
+				Size:     512,
+			}}
+		},
+		CacheStatsFunc: func() models.CacheStats {
+			return models.CacheStats{
+				Entries:   1,
+				Bytes:     512,
+				MaxBytes:  4096,
+				Evictions: map[string]int{"size": 7},
+			}
+		},
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, adminRequest("GET", "/admin?q=star&notice=flushed&n=3", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if gotQuery != "star" {
+		t.Errorf("Expected query to be passed to the model, got %q", gotQuery)
+	}
+	body := w.Body.String()
+	for _, want := range []string{"Star Wars", "tt0076759", "42", "512 B", "512 B / 4096 B", "size 7", `value="id:tt0076759"`, "Caché vaciada, 3 películas eliminadas"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected admin page to contain %q", want)
+		}
+	}
+	if w.Header().Get("Cache-Control") != "no-store" {
+		t.Error("Expected admin pages not to be cached")
+	}
+}
+
+// Test para las acciones de eliminar y vaciar
+func TestAdmin_EvictAndFlush(t *testing.T) {
+	var evicted string
+	handler := newAdminApp(t, &MockMovieModel{
+		EvictFunc: func(key string) bool {
+			evicted = key
+			return true
+		},
+		FlushFunc: func() int { return 7 },
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, adminRequest("POST", "/admin/evict", url.Values{"key": {"id:tt0076759"}}))
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin?notice=evicted" {
+		t.Errorf("Expected redirect after evicting, got %d %s", w.Code, w.Header().Get("Location"))
+	}
+	if evicted != "id:tt0076759" {
+		t.Errorf("Expected entry id:tt0076759 to be evicted, got %q", evicted)
+	}
+
+	req := adminRequest("POST", "/admin/flush", url.Values{})
+	req.Header.Set("Sec-Fetch-Site", "same-origin")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Header().Get("Location") != "/admin?notice=flushed&n=7" {
+		t.Errorf("Expected redirect with the flushed count, got %s", w.Header().Get("Location"))
+	}
+}
+
+// Test para adminWarmHandler: una película por línea, en segundo plano y sin
+// lanzar otra precarga mientras hay una en curso
+func TestAdminWarmHandler(t *testing.T) {
+	release := make(chan struct{})
+	warmed := make(chan []string, 1)
+	handler := newAdminApp(t, &MockMovieModel{
+		WarmFunc: func(queries []string) []models.WarmResult {
+			warmed <- queries
+			<-release
+			return []models.WarmResult{
+				{Query: "Alien", Title: "Alien", ImdbID: "tt0078748"},
+				{Query: "tt0076759", Err: omdb.ErrNotFound},
+			}
+		},
+		CacheEntriesFunc: func(query string) []models.CacheEntry { return nil },
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, adminRequest("POST", "/admin/warm", url.Values{"titles": {"Alien\r\n\n tt0076759 \n"}}))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("Expected status code %d, got %d", http.StatusAccepted, w.Code)
+	}
+	if got := <-warmed; len(got) != 2 || got[0] != "Alien" || got[1] != "tt0076759" {
+		t.Errorf("Expected two trimmed queries, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, adminRequest("POST", "/admin/warm", url.Values{"titles": {"Heat"}}))
+	if w.Code != http.StatusConflict {
+		t.Errorf("Expected status code %d while a warm-up is running, got %d", http.StatusConflict, w.Code)
+	}
+
+	close(release)
+	var status warmupStatus
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, adminRequest("GET", "/admin/warm", nil))
+		if err := json.NewDecoder(w.Body).Decode(&status); err != nil {
+			t.Fatal(err)
+		}
+		if status.State != "running" {
+			break
+		}
+	}
+	if status != (warmupStatus{State: "done", Total: 2, Done: 2, Failed: 1}) {
+		t.Errorf("Expected a finished warm-up with one failure, got %+v", status)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, adminRequest("GET", "/admin", nil))
+	if body := w.Body.String(); !strings.Contains(body, "tt0078748") || !strings.Contains(body, "2 de 2 películas, 1 con error") {
+		t.Errorf("Expected warm results and progress in the page, got %s", body)
+	}
+}
+
+// Test para /admin/export y /admin/import, con el formulario y con el cuerpo en JSON lines
+func TestAdmin_ExportImport(t *testing.T) {
+	var gotPolicy models.ImportPolicy
+	var gotSnapshot string
+	handler := newAdminApp(t, &MockMovieModel{
+		CacheEntriesFunc: func(query string) []models.CacheEntry { return nil },
+		ExportFunc: func(w io.Writer) (int, error) {
+			io.WriteString(w, "snapshot\n")
+			return 1, nil
+		},
+		ImportFunc: func(r io.Reader, policy models.ImportPolicy) (models.ImportStats, error) {
+			body, _ := io.ReadAll(r)
+			gotSnapshot, gotPolicy = string(body), policy
+			if gotSnapshot == "bad" {
+				return models.ImportStats{}, errors.New("no es una instantánea")
+			}
+			return models.ImportStats{Imported: 2, Skipped: 1}, nil
+		},
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, adminRequest("GET", "/admin/export", nil))
+	if w.Code != http.StatusOK || w.Body.String() != "snapshot\n" {
+		t.Errorf("Expected the snapshot, got %d %q", w.Code, w.Body.String())
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Disposition"), "attachment;") {
+		t.Errorf("Expected an attachment, got %q", w.Header().Get("Content-Disposition"))
+	}
+
+	// Formulario de /admin: redirige con el resumen
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, _ := mw.CreateFormFile("snapshot", "cache.jsonl")
+	io.WriteString(part, "lines")
+	mw.WriteField("policy", "skip")
+	mw.Close()
+	req := adminRequest("POST", "/admin/import", nil)
+	req.Body = io.NopCloser(&body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/admin?notice=imported&n=2&skipped=1" {
+		t.Errorf("Expected redirect with the import summary, got %d %q", w.Code, w.Header().Get("Location"))
+	}
+	if gotSnapshot != "lines" || gotPolicy != models.ImportSkip {
+		t.Errorf("Expected the uploaded file with policy skip, got %q %q", gotSnapshot, gotPolicy)
+	}
+
+	// Cuerpo directo: la política por defecto es newer y se responde en JSON
+	req = adminRequest("POST", "/admin/import", nil)
+	req.Body = io.NopCloser(strings.NewReader("raw"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"imported":2`) {
+		t.Errorf("Expected JSON stats, got %d %q", w.Code, w.Body.String())
+	}
+	if gotPolicy != models.ImportNewer {
+		t.Errorf("Expected default policy newer, got %q", gotPolicy)
+	}
+
+	// Con el tipo de un formulario el cuerpo sigue siendo la instantánea y la
+	// política se lee solo de la URL
+	req = adminRequest("POST", "/admin/import?policy=overwrite", nil)
+	req.Body = io.NopCloser(strings.NewReader("policy=skip"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || gotSnapshot != "policy=skip" || gotPolicy != models.ImportOverwrite {
+		t.Errorf("Expected the raw body with policy overwrite, got %d %q %q", w.Code, gotSnapshot, gotPolicy)
+	}
+
+	for _, target := range []string{"/admin/import?policy=merge", "/admin/import"} {
+		req = adminRequest("POST", target, nil)
+		req.Body = io.NopCloser(strings.NewReader("bad"))
+		w = httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"error"`) {
+			t.Errorf("%s: expected a JSON error, got %d %q", target, w.Code, w.Body.String())
+		}
+	}
+}
+
+// Test para la página de una película en inglés: sin textos en español y con
+// números y fechas con el formato del idioma
+func TestMovieHandler_Localized(t *testing.T) {
+	handler := newAdminApp(t, &MockMovieModel{
+		GetByIDFunc: func(imdbID string) (*models.CachedMovie, error) {
+			return &models.CachedMovie{
+				Movie:     &omdb.Movie{Title: "Star Wars", ImdbID: imdbID},
+				FromCache: true,
+				CachedAt:  time.Date(2024, time.August, 5, 14, 3, 9, 0, time.UTC),
+			}, nil
+		},
+		GetCacheStatsFunc: func() (int, int) { return 1234, 5 },
+	})
+
+	for lang, want := range map[string][]string{
+		"en": {"Loaded from cache", "Cache statistics: 1,234 hits / 5 misses", "Aug 5, 2024, 2:03:09 PM", `alt="No poster available"`},
+		"es": {"Cargado desde caché", "Estadísticas de caché: 1234 aciertos / 5 fallos", "5 ago 2024, 14:03:09", `alt="No hay póster disponible"`},
+	} {
+		req := httptest.NewRequest("GET", "/movie/tt0076759/star-wars", nil)
+		req.Header.Set("Accept-Language", lang)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		body := html.UnescapeString(w.Body.String())
+		for _, s := range want {
+			if !strings.Contains(body, s) {
+				t.Errorf("%s: expected the movie page to contain %q", lang, s)
+			}
+		}
+	}
+}
+
+// Test para los errores de OMDB: se traducen y no muestran el error original,
+// que puede incluir la API key
+func TestMovieHandler_LocalizedErrors(t *testing.T) {
+	transportErr := &url.Error{Op: "Get", URL: "https://www.omdbapi.com/?apikey=secret-key&i=tt0076759", Err: errors.New("connection refused")}
+	tests := []struct {
+		err    error
+		status int
+		want   map[string]string
+	}{
+		{omdb.ErrNotFound, http.StatusNotFound, map[string]string{
+			"en": "Error getting movie information: movie not found",
+			"es": "Error al obtener la película: no se encontró la película",
+		}},
+		{fmt.Errorf("%w durante 30s", omdb.ErrCircuitOpen), http.StatusBadGateway, map[string]string{
+			"en": "the movie service is unavailable right now",
+			"es": "el servicio de películas no está disponible ahora mismo",
+		}},
+		{fmt.Errorf("error al hacer la solicitud HTTP: %w", transportErr), http.StatusBadGateway, map[string]string{
+			"en": "Error getting movie information: the movie service could not be reached",
+			"es": "Error al obtener la película: no se pudo consultar el servicio de películas",
+		}},
+	}
+
+	for _, tt := range tests {
+		handler := newAdminApp(t, &MockMovieModel{
+			GetByIDFunc: func(imdbID string) (*models.CachedMovie, error) { return nil, tt.err },
+		})
+		for lang, want := range tt.want {
+			req := httptest.NewRequest("GET", "/movie/tt0076759/star-wars", nil)
+			req.Header.Set("Accept-Language", lang)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+
+			body := html.UnescapeString(w.Body.String())
+			if w.Code != tt.status {
+				t.Errorf("%s: expected status code %d, got %d", lang, tt.status, w.Code)
+			}
+			if !strings.Contains(body, want) {
+				t.Errorf("%s: expected the page to contain %q", lang, want)
+			}
+			for _, leaked := range []string{"secret-key", tt.err.Error()} {
+				if strings.Contains(body, leaked) {
+					t.Errorf("%s: expected the page not to contain %q", lang, leaked)
+				}
+			}
+		}
+	}
+}
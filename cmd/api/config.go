@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/logging"
+)
+
+// Prefijo de las variables de entorno de configuración: --rate-upstream se
+// configura con MOVIES_RATE_UPSTREAM
+const envPrefix = "MOVIES_"
+
+// Opciones que solo se pueden indicar en la línea de comandos
+var commandLineOnly = map[string]bool{"config": true, "print-config": true}
+
+// Opciones con secretos, que no se muestran con --print-config
+var secretSettings = map[string]bool{"apikey": true, "api-tokens": true, "admin-password": true, "peer-secret": true}
+
+// config reúne la configuración de la aplicación. Cada opción se puede indicar,
+// de menor a mayor precedencia, con su valor por defecto, en el archivo de
+// configuración, con una variable de entorno MOVIES_* o con un flag.
+type config struct {
+	Addr   string
+	APIKey string
+
+	TLSCert      string
+	TLSKey       string
+	HTTPRedirect string
+	HTTP2        bool
+
+	TemplateDir string
+	StaticDir   string
+	LocalesDir  string
+	DefaultLang string
+	Dev         bool
+
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	DrainTimeout time.Duration
+
+	ReadyUpstream bool
+	LogFormat     string
+	LogLevel      string
+	CORSOrigins   string
+
+	UpstreamRate   float64
+	UpstreamBurst  int
+	DefaultRate    float64
+	DefaultBurst   int
+	TrustedProxies string
+	APITokens      string
+
+	AdminUser     string
+	AdminPassword string
+
+	CacheMaxBytes      int64
+	CacheCompressAfter time.Duration
+	CacheL1TTL         time.Duration
+	CacheDir           string
+	CacheL2TTL         time.Duration
+	CacheWriteBack     bool
+
+	Peers      string
+	PeerSelf   string
+	PeerSecret string
+
+	WarmFile        string
+	WarmConcurrency int
+	WarmRate        float64
+	WarmBlockReady  bool
+
+	ConfigFile  string
+	PrintConfig bool
+}
+
+// defaultConfig devuelve la configuración por defecto
+func defaultConfig() *config {
+	return &config{
+		Addr:            ":8080",
+		HTTP2:           true,
+		DefaultLang:     "es",
+		ReadTimeout:     defaultReadTimeout,
+		WriteTimeout:    defaultWriteTimeout,
+		IdleTimeout:     defaultIdleTimeout,
+		DrainTimeout:    defaultDrainTimeout,
+		LogFormat:       "text",
+		LogLevel:        "info",
+		UpstreamRate:    1,
+		UpstreamBurst:   10,
+		DefaultRate:     20,
+		DefaultBurst:    60,
+		AdminUser:       "admin",
+		WarmConcurrency: 4,
+		WarmRate:        5,
+	}
+}
+
+// flagSet registra las opciones de c como flags. El mismo conjunto se usa para
+// aplicar el archivo de configuración y las variables de entorno.
+func (c *config) flagSet(output io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(os.Args[0], flag.ContinueOnError)
+	fs.SetOutput(output)
+
+	fs.StringVar(&c.Addr, "addr", c.Addr, "Dirección HTTP")
+	fs.StringVar(&c.APIKey, "apikey", c.APIKey, "API Key para OMDB (también OMDB_API_KEY)")
+	fs.StringVar(&c.TLSCert, "tls-cert", c.TLSCert, "Certificado TLS en PEM; con --tls-key sirve HTTPS (SIGHUP lo recarga)")
+	fs.StringVar(&c.TLSKey, "tls-key", c.TLSKey, "Clave privada del certificado TLS en PEM")
+	fs.StringVar(&c.HTTPRedirect, "http-redirect-addr", c.HTTPRedirect, "Dirección HTTP adicional que redirige a HTTPS (por ejemplo :80)")
+	fs.BoolVar(&c.HTTP2, "http2", c.HTTP2, "Negociar HTTP/2 sobre TLS")
+	fs.StringVar(&c.TemplateDir, "templates", c.TemplateDir, "Ruta a las plantillas (por defecto, las embebidas en el binario)")
+	fs.StringVar(&c.StaticDir, "static", c.StaticDir, "Ruta a los archivos estáticos (por defecto, los embebidos en el binario)")
+	fs.StringVar(&c.LocalesDir, "locales", c.LocalesDir, "Ruta a los archivos de traducción (por defecto, los embebidos en el binario)")
+	fs.StringVar(&c.DefaultLang, "lang", c.DefaultLang, "Idioma predeterminado (es, en)")
+	fs.BoolVar(&c.Dev, "dev", c.Dev, "Modo desarrollo: recarga plantillas y traducciones al cambiar y muestra los errores en el navegador")
+	fs.DurationVar(&c.ReadTimeout, "read-timeout", c.ReadTimeout, "Tiempo máximo para leer una petición")
+	fs.DurationVar(&c.WriteTimeout, "write-timeout", c.WriteTimeout, "Tiempo máximo para escribir una respuesta")
+	fs.DurationVar(&c.IdleTimeout, "idle-timeout", c.IdleTimeout, "Tiempo máximo de inactividad de una conexión keep-alive")
+	fs.DurationVar(&c.DrainTimeout, "drain-timeout", c.DrainTimeout, "Tiempo máximo de espera a las peticiones en curso al apagar")
+	fs.BoolVar(&c.ReadyUpstream, "ready-upstream", c.ReadyUpstream, "Incluir la comprobación de OMDB en /readyz")
+	fs.StringVar(&c.LogFormat, "log-format", c.LogFormat, "Formato de los logs (text, json)")
+	fs.StringVar(&c.LogLevel, "log-level", c.LogLevel, "Nivel mínimo de log (debug, info, warn, error)")
+	fs.StringVar(&c.CORSOrigins, "cors-origins", c.CORSOrigins, "Orígenes permitidos para la API JSON, separados por comas (* para todos)")
+	fs.Float64Var(&c.UpstreamRate, "rate-upstream", c.UpstreamRate, "Peticiones por segundo y cliente a rutas que consultan OMDB (0 desactiva)")
+	fs.IntVar(&c.UpstreamBurst, "burst-upstream", c.UpstreamBurst, "Ráfaga máxima por cliente en rutas que consultan OMDB")
+	fs.Float64Var(&c.DefaultRate, "rate-default", c.DefaultRate, "Peticiones por segundo y cliente al resto de rutas (0 desactiva)")
+	fs.IntVar(&c.DefaultBurst, "burst-default", c.DefaultBurst, "Ráfaga máxima por cliente en el resto de rutas")
+	fs.StringVar(&c.TrustedProxies, "trusted-proxies", c.TrustedProxies, "Redes de proxies cuyos X-Forwarded-For y X-Forwarded-Proto se aceptan, separadas por comas")
+	fs.StringVar(&c.APITokens, "api-tokens", c.APITokens, "Tokens de API con límite propio en lugar del de su IP, separados por comas")
+	fs.StringVar(&c.AdminUser, "admin-user", c.AdminUser, "Usuario del área de administración /admin")
+	fs.StringVar(&c.AdminPassword, "admin-password", c.AdminPassword, "Contraseña del área de administración /admin (vacía la desactiva)")
+	fs.Int64Var(&c.CacheMaxBytes, "cache-max-bytes", c.CacheMaxBytes, "Tamaño máximo aproximado de la caché en bytes (0 sin límite)")
+	fs.DurationVar(&c.CacheCompressAfter, "cache-compress-after", c.CacheCompressAfter, "Comprimir las películas no leídas en este tiempo antes de expulsar ninguna (0 desactiva)")
+	fs.DurationVar(&c.CacheL1TTL, "cache-l1-ttl", c.CacheL1TTL, "Tiempo que una película puede estar en memoria (0 sin caducidad)")
+	fs.StringVar(&c.CacheDir, "cache-dir", c.CacheDir, "Directorio de la caché en disco, segundo nivel bajo la memoria (vacío la desactiva)")
+	fs.DurationVar(&c.CacheL2TTL, "cache-l2-ttl", c.CacheL2TTL, "Antigüedad máxima de una película en la caché en disco (0 sin caducidad)")
+	fs.BoolVar(&c.CacheWriteBack, "cache-write-back", c.CacheWriteBack, "Guardar en disco en segundo plano en lugar de en cada petición")
+	fs.StringVar(&c.Peers, "peers", c.Peers, "URLs de todas las réplicas que comparten la caché, incluida esta, separadas por comas")
+	fs.StringVar(&c.PeerSelf, "peer-self", c.PeerSelf, "URL de esta réplica tal como aparece en --peers")
+	fs.StringVar(&c.PeerSecret, "peer-secret", c.PeerSecret, "Secreto compartido que autentica las peticiones entre réplicas")
+	fs.StringVar(&c.WarmFile, "warm", c.WarmFile, "Archivo con títulos o IDs de IMDb, uno por línea, para precargar la caché al arrancar")
+	fs.IntVar(&c.WarmConcurrency, "warm-concurrency", c.WarmConcurrency, "Películas que se precargan a la vez")
+	fs.Float64Var(&c.WarmRate, "warm-rate", c.WarmRate, "Consultas por segundo a OMDB durante la precarga (0 sin límite)")
+	fs.BoolVar(&c.WarmBlockReady, "warm-block-ready", c.WarmBlockReady, "No indicar disponibilidad en /readyz hasta terminar la precarga")
+	fs.StringVar(&c.ConfigFile, "config", c.ConfigFile, "Archivo de configuración JSON (también MOVIES_CONFIG)")
+	fs.BoolVar(&c.PrintConfig, "print-config", c.PrintConfig, "Mostrar la configuración final, sin secretos, y salir")
+
+	return fs
+}
+
+// loadConfig construye la configuración a partir de los valores por defecto, el
+// archivo de configuración, las variables de entorno y los argumentos args.
+// La configuración devuelta aún no se ha validado.
+func loadConfig(args []string, lookupEnv func(string) (string, bool), output io.Writer) (*config, error) {
+	// Una primera pasada por los flags indica qué archivo de configuración leer
+	pre := defaultConfig()
+	if err := pre.flagSet(output).Parse(args); err != nil {
+		return nil, err
+	}
+	if pre.ConfigFile == "" {
+		pre.ConfigFile, _ = lookupEnv(envPrefix + "CONFIG")
+	}
+
+	cfg := defaultConfig()
+	fs := cfg.flagSet(io.Discard)
+
+	if pre.ConfigFile != "" {
+		if err := applyConfigFile(fs, pre.ConfigFile); err != nil {
+			return nil, err
+		}
+	}
+	if err := applyEnv(fs, lookupEnv); err != nil {
+		return nil, err
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	cfg.ConfigFile = pre.ConfigFile
+	return cfg, nil
+}
+
+// applyConfigFile aplica las opciones de un archivo JSON cuyas claves son los
+// nombres de los flags. Las listas se pueden escribir como arrays.
+func applyConfigFile(fs *flag.FlagSet, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("archivo de configuración: %w", err)
+	}
+
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("archivo de configuración %s: %w", path, err)
+	}
+
+	for name, raw := range values {
+		f := fs.Lookup(name)
+		if f == nil || commandLineOnly[name] {
+			return fmt.Errorf("archivo de configuración %s: opción desconocida %q", path, name)
+		}
+		value, err := rawConfigValue(raw)
+		if err != nil {
+			return fmt.Errorf("archivo de configuración %s: %s: %w", path, name, err)
+		}
+		if err := f.Value.Set(value); err != nil {
+			return fmt.Errorf("archivo de configuración %s: %s: %w", path, name, err)
+		}
+	}
+	return nil
+}
+
+// rawConfigValue convierte un valor JSON en el texto que acepta su flag
+func rawConfigValue(raw json.RawMessage) (string, error) {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return s, nil
+	}
+
+	var list []string
+	if err := json.Unmarshal(raw, &list); err == nil {
+		return strings.Join(list, ","), nil
+	}
+
+	// Números y booleanos se usan tal cual
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", err
+	}
+	switch v.(type) {
+	case float64, bool:
+		return string(raw), nil
+	}
+	return "", errors.New("se esperaba un texto, un número, un booleano o una lista de textos")
+}
+
+// applyEnv aplica las variables de entorno MOVIES_*. OMDB_API_KEY se admite
+// por compatibilidad, con menos precedencia que MOVIES_APIKEY.
+func applyEnv(fs *flag.FlagSet, lookupEnv func(string) (string, bool)) error {
+	if v, ok := lookupEnv("OMDB_API_KEY"); ok {
+		fs.Set("apikey", v)
+	}
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || commandLineOnly[f.Name] {
+			return
+		}
+		name := envName(f.Name)
+		if v, ok := lookupEnv(name); ok {
+			if setErr := f.Value.Set(v); setErr != nil {
+				err = fmt.Errorf("%s: %w", name, setErr)
+			}
+		}
+	})
+	return err
+}
+
+// envName devuelve la variable de entorno de un flag: rate-upstream → MOVIES_RATE_UPSTREAM
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// validate comprueba que la configuración es coherente y devuelve todos los errores encontrados
+func (c *config) validate() error {
+	var errs []error
+
+	if c.APIKey == "" {
+		errs = append(errs, errors.New("falta la API key de OMDB: use --apikey, MOVIES_APIKEY u OMDB_API_KEY"))
+	}
+	if c.Addr == "" {
+		errs = append(errs, errors.New("addr: no puede estar vacía"))
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, errors.New("tls-cert y tls-key se deben indicar juntos"))
+	}
+	if c.HTTPRedirect != "" && c.TLSCert == "" {
+		errs = append(errs, errors.New("http-redirect-addr: requiere tls-cert y tls-key"))
+	}
+	if c.DefaultLang == "" {
+		errs = append(errs, errors.New("lang: no puede estar vacío"))
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		errs = append(errs, fmt.Errorf("log-format: formato %q no válido (text, json)", c.LogFormat))
+	}
+	if _, err := logging.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log-level: %w", err))
+	}
+
+	timeouts := []struct {
+		name string
+		d    time.Duration
+	}{
+		{"read-timeout", c.ReadTimeout},
+		{"write-timeout", c.WriteTimeout},
+		{"idle-timeout", c.IdleTimeout},
+		{"drain-timeout", c.DrainTimeout},
+	}
+	for _, t := range timeouts {
+		if t.d < 0 {
+			errs = append(errs, fmt.Errorf("%s: no puede ser negativo", t.name))
+		}
+	}
+	if c.UpstreamRate < 0 || c.DefaultRate < 0 {
+		errs = append(errs, errors.New("rate-upstream y rate-default no pueden ser negativos"))
+	}
+	if c.UpstreamBurst < 1 || c.DefaultBurst < 1 {
+		errs = append(errs, errors.New("burst-upstream y burst-default deben ser al menos 1"))
+	}
+	if c.AdminPassword != "" && c.AdminUser == "" {
+		errs = append(errs, errors.New("admin-user: no puede estar vacío si hay contraseña"))
+	}
+	if c.CacheMaxBytes < 0 {
+		errs = append(errs, errors.New("cache-max-bytes: no puede ser negativo"))
+	}
+	if c.CacheCompressAfter < 0 || c.CacheL1TTL < 0 || c.CacheL2TTL < 0 {
+		errs = append(errs, errors.New("cache-compress-after, cache-l1-ttl y cache-l2-ttl no pueden ser negativos"))
+	}
+	if c.CacheWriteBack && c.CacheDir == "" {
+		errs = append(errs, errors.New("cache-write-back: requiere cache-dir"))
+	}
+	if c.Peers != "" {
+		self := false
+		for _, peer := range splitList(c.Peers) {
+			self = self || strings.TrimSuffix(peer, "/") == strings.TrimSuffix(c.PeerSelf, "/")
+		}
+		if !self {
+			errs = append(errs, errors.New("peer-self: debe ser una de las URLs de peers"))
+		}
+		// Sin secreto cualquiera podría pedir películas a OMDB a través de la réplica
+		if c.PeerSecret == "" {
+			errs = append(errs, errors.New("peer-secret: no puede estar vacío si hay peers"))
+		}
+	} else if c.PeerSelf != "" {
+		errs = append(errs, errors.New("peer-self: requiere peers"))
+	}
+	if c.WarmConcurrency < 1 {
+		errs = append(errs, errors.New("warm-concurrency: debe ser al menos 1"))
+	}
+	if c.WarmRate < 0 {
+		errs = append(errs, errors.New("warm-rate: no puede ser negativo"))
+	}
+	if _, err := parseCIDRs(splitList(c.TrustedProxies)); err != nil {
+		errs = append(errs, fmt.Errorf("trusted-proxies: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// printConfig escribe la configuración final en formato de archivo de
+// configuración, con los secretos ocultos
+func (c *config) printConfig(w io.Writer) error {
+	values := make(map[string]interface{})
+	c.flagSet(io.Discard).VisitAll(func(f *flag.Flag) {
+		if commandLineOnly[f.Name] {
+			return
+		}
+		if secretSettings[f.Name] {
+			if f.Value.String() != "" {
+				values[f.Name] = "*****"
+			} else {
+				values[f.Name] = ""
+			}
+			return
+		}
+
+		v := f.Value.(flag.Getter).Get()
+		if d, ok := v.(time.Duration); ok {
+			v = d.String()
+		}
+		values[f.Name] = v
+	})
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(values)
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// envMap simula las variables de entorno
+func envMap(vars map[string]string) func(string) (string, bool) {
+	return func(name string) (string, bool) {
+		v, ok := vars[name]
+		return v, ok
+	}
+}
+
+// writeConfigFile escribe un archivo de configuración temporal
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Test para loadConfig: precedencia por defecto < archivo < entorno < flags
+func TestLoadConfig_Precedence(t *testing.T) {
+	path := writeConfigFile(t, `{
+		"addr": ":9000",
+		"lang": "en",
+		"rate-upstream": 2.5,
+		"ready-upstream": true,
+		"read-timeout": "3s",
+		"cors-origins": ["https://a.example.com", "https://b.example.com"]
+	}`)
+
+	env := envMap(map[string]string{
+		"MOVIES_CONFIG": path,
+		"MOVIES_LANG":   "es",
+		"OMDB_API_KEY":  "from-omdb-env",
+	})
+	cfg, err := loadConfig([]string{"--addr", ":7000"}, env, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Addr != ":7000" {
+		t.Errorf("Expected flag to override the file, got %s", cfg.Addr)
+	}
+	if cfg.DefaultLang != "es" {
+		t.Errorf("Expected environment to override the file, got %s", cfg.DefaultLang)
+	}
+	if cfg.UpstreamRate != 2.5 || !cfg.ReadyUpstream || cfg.ReadTimeout != 3*time.Second {
+		t.Errorf("Expected values from the file, got %+v", cfg)
+	}
+	if cfg.CORSOrigins != "https://a.example.com,https://b.example.com" {
+		t.Errorf("Expected list from the file, got %s", cfg.CORSOrigins)
+	}
+	if cfg.APIKey != "from-omdb-env" {
+		t.Errorf("Expected OMDB_API_KEY to be used, got %s", cfg.APIKey)
+	}
+	if cfg.DefaultBurst != 60 {
+		t.Errorf("Expected default value, got %d", cfg.DefaultBurst)
+	}
+	if cfg.ConfigFile != path {
+		t.Errorf("Expected config file from MOVIES_CONFIG, got %s", cfg.ConfigFile)
+	}
+
+	// MOVIES_APIKEY tiene precedencia sobre OMDB_API_KEY
+	cfg, err = loadConfig(nil, envMap(map[string]string{
+		"OMDB_API_KEY":  "old",
+		"MOVIES_APIKEY": "new",
+	}), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.APIKey != "new" {
+		t.Errorf("Expected MOVIES_APIKEY to win, got %s", cfg.APIKey)
+	}
+}
+
+// Test para loadConfig: errores en el archivo y en el entorno
+func TestLoadConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		env  map[string]string
+	}{
+		{"unknown key", `{"unknown": 1}`, nil},
+		{"command line only key", `{"print-config": true}`, nil},
+		{"wrong type", `{"burst-default": "many"}`, nil},
+		{"invalid json", `{`, nil},
+		{"invalid env value", `{}`, map[string]string{"MOVIES_READ_TIMEOUT": "soon"}},
+	}
+
+	for _, tt := range tests {
+		path := writeConfigFile(t, tt.file)
+		if _, err := loadConfig([]string{"--config", path}, envMap(tt.env), io.Discard); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// Test para config.validate: informa de todos los errores a la vez
+func TestConfig_Validate(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.APIKey = "key"
+	if err := cfg.validate(); err != nil {
+		t.Fatalf("Expected default config with an API key to be valid, got %v", err)
+	}
+
+	cfg = defaultConfig()
+	cfg.LogFormat = "xml"
+	cfg.DefaultBurst = 0
+	cfg.TrustedProxies = "not-a-network"
+	cfg.Peers = "http://a:8080,http://b:8080"
+	cfg.PeerSelf = "http://c:8080"
+	err := cfg.validate()
+	if err == nil {
+		t.Fatal("Expected validation errors")
+	}
+	for _, want := range []string{"API key", "log-format", "burst", "trusted-proxies", "peer-self", "peer-secret"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %s, got %v", want, err)
+		}
+	}
+}
+
+// Test para config.printConfig: los secretos se ocultan y la salida sirve como archivo
+func TestConfig_PrintConfig(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.APIKey = "super-secret"
+	cfg.APITokens = "token1,token2"
+
+	var buf bytes.Buffer
+	if err := cfg.printConfig(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "super-secret") || strings.Contains(buf.String(), "token1") {
+		t.Errorf("Expected secrets to be redacted, got %s", buf.String())
+	}
+
+	var values map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &values); err != nil {
+		t.Fatal(err)
+	}
+	if values["read-timeout"] != "10s" || values["burst-default"] != float64(60) {
+		t.Errorf("Expected typed values, got %v", values)
+	}
+	if _, ok := values["config"]; ok {
+		t.Error("Expected command line only options to be omitted")
+	}
+
+	// Sin los secretos, la salida se puede usar como archivo de configuración
+	delete(values, "apikey")
+	delete(values, "api-tokens")
+	data, _ := json.Marshal(values)
+	path := writeConfigFile(t, string(data))
+	loaded, err := loadConfig([]string{"--config", path}, envMap(nil), io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ReadTimeout != cfg.ReadTimeout || loaded.DefaultBurst != cfg.DefaultBurst {
+		t.Errorf("Expected round trip to keep values, got %+v", loaded)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"html/template"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/fswatch"
+)
+
+// Cada cuánto se comprueban los cambios en plantillas y traducciones en modo desarrollo
+const devPollInterval = 500 * time.Millisecond
+
+// devErrorTemplate muestra los errores de carga sin depender de las plantillas
+// de la aplicación, que pueden ser justo las que fallan
+var devErrorTemplate = template.Must(template.New("dev-error").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="UTF-8"><title>Error de carga</title></head>
+<body>
+<h1>Error de carga en modo desarrollo</h1>
+{{range .}}<h2>{{.Source}}</h2>
+<pre>{{.Err}}</pre>
+{{end}}<p>Corrige el archivo y recarga la página.</p>
+</body>
+</html>
+`))
+
+// devError es un error de carga de una fuente de recursos (plantillas o traducciones)
+type devError struct {
+	Source string
+	Err    error
+}
+
+// setDevError guarda (o limpia, si err es nil) el último error de carga de source
+func (app *application) setDevError(source string, err error) {
+	app.tmplMu.Lock()
+	defer app.tmplMu.Unlock()
+
+	if app.devErrors == nil {
+		app.devErrors = make(map[string]error)
+	}
+	if err == nil {
+		delete(app.devErrors, source)
+		return
+	}
+	app.devErrors[source] = err
+}
+
+// renderDevError muestra los errores de carga pendientes, si los hay
+func (app *application) renderDevError(w http.ResponseWriter) bool {
+	app.tmplMu.RLock()
+	errs := make([]devError, 0, len(app.devErrors))
+	for source, err := range app.devErrors {
+		errs = append(errs, devError{Source: source, Err: err})
+	}
+	app.tmplMu.RUnlock()
+
+	if len(errs) == 0 {
+		return false
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Source < errs[j].Source })
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	devErrorTemplate.Execute(w, errs)
+	return true
+}
+
+// reloadTemplates vuelve a cargar las plantillas y las sustituye de una sola vez.
+// Si alguna no compila se conservan las anteriores y se guarda el error.
+func (app *application) reloadTemplates(fsys fs.FS) error {
+	templates, err := loadTemplates(fsys)
+	if err == nil {
+		app.tmplMu.Lock()
+		app.templates = templates
+		app.langTemplates = nil
+		app.tmplMu.Unlock()
+	}
+	app.setDevError("templates", err)
+	return err
+}
+
+// watchTemplates recarga las plantillas cada vez que cambian, hasta que ctx se cancela
+func (app *application) watchTemplates(ctx context.Context, fsys fs.FS) {
+	fswatch.Poll(ctx, fsys, devPollInterval, func() {
+		if err := app.reloadTemplates(fsys); err != nil {
+			slog.Error("template reload failed", "error", err)
+			return
+		}
+		slog.Info("templates reloaded")
+	})
+}
+
+// watchLocales recarga las traducciones cada vez que cambian, hasta que ctx se cancela
+func (app *application) watchLocales(ctx context.Context, reloader localeReloader) {
+	reloader.Watch(ctx, devPollInterval, func(err error) {
+		app.setDevError("locales", err)
+		if err != nil {
+			slog.Error("locale reload failed", "error", err)
+			return
+		}
+		slog.Info("locales reloaded")
+	})
+}
+
+// localeReloader lo implementan los traductores que pueden recargar sus archivos
+type localeReloader interface {
+	Watch(ctx context.Context, interval time.Duration, onReload func(error))
+}
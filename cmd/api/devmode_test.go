@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// Test para reloadTemplates: las plantillas nuevas sustituyen a las anteriores
+// y un error de sintaxis se muestra en el navegador sin perderlas
+func TestReloadTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layout.html": {Data: []byte(`{{template "content" .}}`)},
+		"home.html":   {Data: []byte(`{{define "content"}}v1{{end}}`)},
+	}
+	app := &application{
+		translator: &MockTranslator{
+			TFunc: func(lang, key string) string { return key },
+		},
+		defaultLang: "es",
+		dev:         true,
+	}
+
+	render := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		app.render(w, httptest.NewRequest("GET", "/", nil), "home.html", &viewData{})
+		return w
+	}
+
+	if err := app.reloadTemplates(fsys); err != nil {
+		t.Fatal(err)
+	}
+	if body := render().Body.String(); body != "v1" {
+		t.Fatalf("Expected v1, got %q", body)
+	}
+
+	fsys["home.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}v2{{end}}`)}
+	if err := app.reloadTemplates(fsys); err != nil {
+		t.Fatal(err)
+	}
+	if body := render().Body.String(); body != "v2" {
+		t.Fatalf("Expected v2 after reload, got %q", body)
+	}
+
+	fsys["home.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}{{.Broken{{end}}`)}
+	if err := app.reloadTemplates(fsys); err == nil {
+		t.Fatal("Expected parse error")
+	}
+	w := render()
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if !strings.Contains(w.Body.String(), "home.html") {
+		t.Errorf("Expected parse error in the page, got %q", w.Body.String())
+	}
+	if !app.hasTemplate("home.html") {
+		t.Error("Expected previous templates to be kept")
+	}
+
+	// Al corregir el archivo desaparece el error
+	fsys["home.html"] = &fstest.MapFile{Data: []byte(`{{define "content"}}v3{{end}}`)}
+	if err := app.reloadTemplates(fsys); err != nil {
+		t.Fatal(err)
+	}
+	if body := render().Body.String(); body != "v3" {
+		t.Errorf("Expected v3 once fixed, got %q", body)
+	}
+}
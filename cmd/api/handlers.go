@@ -2,15 +2,25 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"html/template"
+	"io/fs"
+	"log/slog"
+	"net"
 	"net/http"
-	"path/filepath"
-	"strings"
+	"net/url"
+	"path"
+	"sync"
 	"time"
 
 	"github.com/prosales/go-api-movies/pkg/i18n"
 	"github.com/prosales/go-api-movies/pkg/models"
 	"github.com/prosales/go-api-movies/pkg/omdb"
+	"github.com/prosales/go-api-movies/pkg/peers"
+	"github.com/prosales/go-api-movies/pkg/textnorm"
 )
 
 // Estructura para almacenar el contexto de los handlers
@@ -19,24 +29,215 @@ type application struct {
 	templates   map[string]*template.Template
 	translator  i18n.TranslatorInterface
 	defaultLang string
+
+	// Comprobación opcional de OMDB para /readyz
+	upstream      pinger
+	readyUpstream bool
+	startedAt     time.Time
+
+	// Precarga de la caché al arrancar, si se indicó --warm
+	warmup          *warmup
+	warmBlocksReady bool
+	// Concurrencia y ritmo de todas las precargas, también las de /admin/warm
+	warmOptions models.WarmOptions
+	// Última precarga lanzada desde /admin/warm
+	adminWarmMu sync.Mutex
+	adminWarm   *warmup
+
+	// baseCtx se cancela al apagar el servidor; lo usan las tareas que siguen
+	// después de responder
+	baseCtx context.Context
+
+	// Orígenes con acceso CORS a la API JSON
+	corsOrigins []string
+
+	// Límites de tasa para las rutas que consultan OMDB y para el resto
+	upstreamLimiter *rateLimiter
+	defaultLimiter  *rateLimiter
+	trustedProxies  []*net.IPNet
+	apiTokens       map[string]bool
+
+	// Credenciales del área de administración; sin contraseña no se registra
+	adminUser     string
+	adminPassword string
+
+	// Caché compartida con otras réplicas; sin ella no se atienden sus peticiones
+	peerLoader peers.LoadFunc
+	peerSecret string
+
+	// En modo desarrollo las plantillas se recargan al cambiar y los errores
+	// de carga se muestran en el navegador
+	dev       bool
+	devErrors map[string]error
+
+	// tmplMu protege templates, devErrors y las copias de las plantillas por
+	// idioma (ver application.template)
+	tmplMu        sync.RWMutex
+	langTemplates map[string]map[string]*template.Template
+}
+
+// hasTemplate indica si la plantilla de una página está cargada
+func (app *application) hasTemplate(name string) bool {
+	app.tmplMu.RLock()
+	defer app.tmplMu.RUnlock()
+	_, ok := app.templates[name]
+	return ok
+}
+
+// template devuelve la plantilla de una página con la función t ligada al idioma
+// lang. Las plantillas cargadas no se ejecutan nunca: cada idioma usa su propia
+// copia, creada la primera vez que se pide, para que las peticiones concurrentes
+// en distintos idiomas no compartan la función t.
+func (app *application) template(name, lang string) (*template.Template, error) {
+	lang = app.templateLang(lang)
+
+	app.tmplMu.RLock()
+	t, ok := app.langTemplates[lang][name]
+	app.tmplMu.RUnlock()
+	if ok {
+		return t, nil
+	}
+
+	app.tmplMu.Lock()
+	defer app.tmplMu.Unlock()
+
+	if t, ok := app.langTemplates[lang][name]; ok {
+		return t, nil
+	}
+	base, ok := app.templates[name]
+	if !ok {
+		return nil, fmt.Errorf("plantilla %s no cargada", name)
+	}
+	t, err := base.Clone()
+	if err != nil {
+		return nil, err
+	}
+	t.Funcs(template.FuncMap{
+		"t": func(key string) string {
+			return app.translator.T(lang, key)
+		},
+		"tf": func(key string, pairs ...any) (string, error) {
+			args, err := templateArgs(pairs)
+			return app.translator.Tf(lang, key, args), err
+		},
+		"tn": func(key string, n int, pairs ...any) (string, error) {
+			args, err := templateArgs(pairs)
+			return app.translator.Tn(lang, key, n, args), err
+		},
+		"languages": app.languages,
+	})
+	t.Funcs(formatFuncs(lang))
+
+	if app.langTemplates == nil {
+		app.langTemplates = make(map[string]map[string]*template.Template)
+	}
+	if app.langTemplates[lang] == nil {
+		app.langTemplates[lang] = make(map[string]*template.Template)
+	}
+	app.langTemplates[lang][name] = t
+	return t, nil
+}
+
+// templateArgs convierte los argumentos de tf y tn en las plantillas, pares
+// de nombre y valor como en {{tf "no_results" "query" .Query}}, en i18n.Args
+func templateArgs(pairs []any) (i18n.Args, error) {
+	if len(pairs)%2 != 0 {
+		return nil, fmt.Errorf("se esperan pares de nombre y valor, hay %d argumentos", len(pairs))
+	}
+	args := make(i18n.Args, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		name, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("el nombre del argumento %d no es un texto: %v", i/2+1, pairs[i])
+		}
+		args[name] = pairs[i+1]
+	}
+	return args, nil
+}
+
+// formatFuncs devuelve las funciones de las plantillas que dan formato a
+// números, fechas y duraciones según las convenciones de lang
+func formatFuncs(lang string) template.FuncMap {
+	return template.FuncMap{
+		"formatNumber": func(n any) (string, error) {
+			switch n := n.(type) {
+			case int:
+				return i18n.FormatNumber(lang, int64(n)), nil
+			case int64:
+				return i18n.FormatNumber(lang, n), nil
+			case float64:
+				return i18n.FormatDecimal(lang, n, 0), nil
+			}
+			return "", fmt.Errorf("formatNumber: tipo %T no soportado", n)
+		},
+		"formatDecimal": func(f float64, prec int) string {
+			return i18n.FormatDecimal(lang, f, prec)
+		},
+		"formatDate": func(t time.Time) string {
+			return i18n.FormatDate(lang, t)
+		},
+		"formatDateTime": func(t time.Time) string {
+			return i18n.FormatDateTime(lang, t)
+		},
+		"formatDuration": func(d time.Duration) string {
+			return i18n.FormatDuration(lang, d)
+		},
+		"since": time.Since,
+	}
+}
+
+// language es un idioma del menú de idiomas, con su nombre en ese idioma
+type language struct {
+	Code string
+	Name string
+}
+
+// availableLanguages devuelve los idiomas que tiene cargados el traductor. Sin
+// traductor solo se conoce el idioma predeterminado.
+func (app *application) availableLanguages() []string {
+	if app.translator == nil {
+		return []string{app.defaultLang}
+	}
+	return app.translator.AvailableLanguages()
+}
+
+// languages devuelve los idiomas que tiene cargados el traductor
+func (app *application) languages() []language {
+	var langs []language
+	for _, code := range app.availableLanguages() {
+		langs = append(langs, language{Code: code, Name: app.translator.T(code, "language_name")})
+	}
+	return langs
+}
+
+// templateLang limita los idiomas con copia propia de las plantillas a los que
+// tiene el traductor; cualquier otro se traduciría igual que el predeterminado
+func (app *application) templateLang(lang string) string {
+	if lang, ok := i18n.MatchTag(lang, app.availableLanguages()); ok {
+		return lang
+	}
+	return app.defaultLang
 }
 
 // Función para renderizar plantillas
 func (app *application) render(w http.ResponseWriter, r *http.Request, tmpl string, data interface{}) {
-	t, ok := app.templates[tmpl]
-	if !ok {
-		http.Error(w, "Template not found", http.StatusInternalServerError)
+	app.renderStatus(w, r, http.StatusOK, tmpl, data)
+}
+
+// renderStatus renderiza una plantilla con el código de estado indicado
+func (app *application) renderStatus(w http.ResponseWriter, r *http.Request, status int, tmpl string, data interface{}) {
+	if app.dev && app.renderDevError(w) {
 		return
 	}
 
 	// Obtener el idioma desde la cookie o parámetro
 	lang := app.getLangFromRequest(r)
-	
-	// Crear un mapa de funciones para las plantillas que incluya t
-	funcMap := template.FuncMap{
-		"t": func(key string) string {
-			return app.translator.T(lang, key)
-		},
+
+	// Plantilla con la función t ya ligada al idioma de la petición
+	t, err := app.template(tmpl, lang)
+	if err != nil {
+		http.Error(w, "Template not found", http.StatusInternalServerError)
+		return
 	}
 
 	// Si data es un viewData, actualiza el lang
@@ -44,49 +245,64 @@ func (app *application) render(w http.ResponseWriter, r *http.Request, tmpl stri
 		vd.Lang = lang
 	}
 
-	// Copiar el template y añadir las funciones de i18n
-	// Como no podemos modificar directamente el template, creamos un nuevo
-	// buffer para guardar el resultado
+	// Renderizamos en un buffer para poder responder con un error si falla
 	var buf bytes.Buffer
-	// Ejecutamos el template con los datos y funciones
-	if err := t.Funcs(funcMap).Execute(&buf, data); err != nil {
+	if err := t.Execute(&buf, data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 	
 	// Escribimos el resultado al ResponseWriter
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
 	buf.WriteTo(w)
 }
 
-// getLangFromRequest obtiene el idioma de la solicitud (cookie, query param, o header Accept-Language)
+// renderError muestra la página de error traducida con el mensaje de la clave indicada
+func (app *application) renderError(w http.ResponseWriter, r *http.Request, status int, key string) {
+	lang := app.getLangFromRequest(r)
+	message := app.translator.T(lang, key)
+
+	if !app.hasTemplate("error.html") {
+		http.Error(w, message, status)
+		return
+	}
+
+	app.renderStatus(w, r, status, "error.html", &viewData{
+		Error:  message,
+		Status: status,
+		Lang:   lang,
+	})
+}
+
+// writeJSON escribe v como respuesta JSON con el código de estado indicado
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// getLangFromRequest obtiene el idioma de la solicitud (query param, cookie o
+// header Accept-Language), entre los que tiene cargados el traductor
 func (app *application) getLangFromRequest(r *http.Request) string {
+	available := app.availableLanguages()
+
 	// 1. Verificar el parámetro de consulta lang
-	if lang := r.URL.Query().Get("lang"); lang != "" {
+	if lang, ok := i18n.MatchTag(r.URL.Query().Get("lang"), available); ok {
 		// No podemos establecer cookies aquí, solo devolver el idioma
 		return lang
 	}
 
 	// 2. Verificar cookie
 	if cookie, err := r.Cookie("lang"); err == nil {
-		return cookie.Value
-	}
-
-	// 3. Verificar encabezado Accept-Language
-	acceptLang := r.Header.Get("Accept-Language")
-	if acceptLang != "" {
-		// Parsear y obtener el primer idioma preferido
-		langs := strings.Split(acceptLang, ",")
-		if len(langs) > 0 {
-			// Extraer solo el código de idioma (es-ES -> es)
-			langCode := strings.Split(langs[0], "-")[0]
-			if langCode == "es" || langCode == "en" {
-				return langCode
-			}
+		if lang, ok := i18n.MatchTag(cookie.Value, available); ok {
+			return lang
 		}
 	}
 
-	// 4. Usar idioma predeterminado
-	return app.defaultLang
+	// 3. Negociar con el encabezado Accept-Language, o usar el idioma predeterminado
+	return i18n.Negotiate(r.Header.Get("Accept-Language"), available, app.defaultLang)
 }
 
 // Estructura para datos comunes en las vistas
@@ -95,20 +311,23 @@ type viewData struct {
 	Query  string
 	Movies []omdb.Movie
 	Lang   string
+	Status int
 	*omdb.Movie
 	FromCache  bool
 	CachedAt   time.Time
 	CacheHits  int
 	CacheMisses int
+
+	// Área de administración
+	CacheStats   *models.CacheStats
+	CacheEntries []models.CacheEntry
+	WarmResults  []models.WarmResult
+	WarmStatus   *warmupStatus
+	Notice       string
 }
 
 // Handler para la página principal
 func (app *application) homeHandler(w http.ResponseWriter, r *http.Request) {
-	if r.URL.Path != "/" {
-		http.NotFound(w, r)
-		return
-	}
-
 	data := &viewData{
 		Lang: app.getLangFromRequest(r),
 	}
@@ -117,8 +336,8 @@ func (app *application) homeHandler(w http.ResponseWriter, r *http.Request) {
 
 // Handler para cambiar el idioma
 func (app *application) changeLangHandler(w http.ResponseWriter, r *http.Request) {
-	lang := r.URL.Query().Get("lang")
-	if lang != "es" && lang != "en" {
+	lang, ok := i18n.MatchTag(r.URL.Query().Get("lang"), app.availableLanguages())
+	if !ok {
 		lang = app.defaultLang
 	}
 
@@ -153,9 +372,9 @@ func (app *application) searchHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := app.movieModel.Search(query)
+	result, err := app.movieModel.Search(r.Context(), query)
 	if err != nil {
-		data.Error = app.translator.T(lang, "error_search") + ": " + err.Error()
+		data.Error = app.upstreamError(r, lang, "error_search", err)
 		app.render(w, r, "search.html", data)
 		return
 	}
@@ -169,33 +388,86 @@ func (app *application) searchHandler(w http.ResponseWriter, r *http.Request) {
 	app.render(w, r, "search.html", data)
 }
 
-// Handler para los detalles de una película
+// Claves de los errores de OMDB que se explican al usuario
+var upstreamErrorKeys = map[error]string{
+	omdb.ErrNotFound:    "error_movie_not_found",
+	omdb.ErrCircuitOpen: "error_upstream_unavailable",
+}
+
+// upstreamError traduce un error al consultar películas: key describe la
+// operación y err se explica con upstreamErrorKeys. El texto de los demás
+// errores no se muestra, porque está en español y puede incluir la URL de OMDB
+// con la API key: solo se registra.
+func (app *application) upstreamError(r *http.Request, lang, key string, err error) string {
+	for target, reason := range upstreamErrorKeys {
+		if errors.Is(err, target) {
+			return app.translator.T(lang, key) + ": " + app.translator.T(lang, reason)
+		}
+	}
+	slog.WarnContext(r.Context(), "upstream request failed", "path", r.URL.Path, "error", err)
+	return app.translator.T(lang, key) + ": " + app.translator.T(lang, "error_upstream")
+}
+
+// movieURL devuelve la URL canónica de una película: /movie/{imdbID}/{slug}
+func movieURL(imdbID, title string) string {
+	u := "/movie/" + url.PathEscape(imdbID)
+	if slug := textnorm.Slug(title); slug != "" {
+		u += "/" + url.PathEscape(slug)
+	}
+	return u
+}
+
+// Handler para los detalles de una película: /movie/{imdbID} y /movie/{imdbID}/{slug}
 func (app *application) movieHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("imdbID")
+	lang := app.getLangFromRequest(r)
+
+	if !omdb.IsIMDbID(id) {
+		app.renderError(w, r, http.StatusNotFound, "error_not_found")
+		return
+	}
+
+	cachedMovie, err := app.movieModel.GetByID(r.Context(), id)
+	if err != nil {
+		status := http.StatusBadGateway
+		if errors.Is(err, omdb.ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		app.renderStatus(w, r, status, "movie.html", &viewData{
+			Error: app.upstreamError(r, lang, "error_movie", err),
+			Lang:  lang,
+		})
+		return
+	}
+
+	// Redirigir a la URL canónica si falta el slug o no coincide con el título
+	if r.PathValue("slug") != textnorm.Slug(cachedMovie.Movie.Title) {
+		canonical := movieURL(id, cachedMovie.Movie.Title)
+		if r.URL.RawQuery != "" {
+			canonical += "?" + r.URL.RawQuery
+		}
+		http.Redirect(w, r, canonical, http.StatusMovedPermanently)
+		return
+	}
+
+	app.renderMovie(w, r, cachedMovie)
+}
+
+// Handler para las URLs antiguas /movie?id=... y /movie?t=..., que redirige
+// de forma permanente a la URL canónica de la película
+func (app *application) legacyMovieHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
 	title := r.URL.Query().Get("t")
 	lang := app.getLangFromRequest(r)
 
-	var cachedMovie *models.CachedMovie
-	var err error
-
-	// Si se proporciona un ID, redirigimos a la API directamente
+	// Con el ID no hace falta consultar nada para saber la nueva URL
 	if id != "" {
-		http.Redirect(w, r, "https://www.imdb.com/title/"+id, http.StatusSeeOther)
+		http.Redirect(w, r, movieURL(id, ""), http.StatusMovedPermanently)
 		return
 	}
 
-	// Si no hay ID pero hay título, buscamos por título
-	if title != "" {
-		cachedMovie, err = app.movieModel.GetByTitle(title)
-		if err != nil {
-			app.render(w, r, "movie.html", &viewData{
-				Error: app.translator.T(lang, "error_movie") + ": " + err.Error(),
-				Lang:  lang,
-			})
-			return
-		}
-	} else {
-		// Si no hay ni ID ni título, mostramos un error
+	// Si no hay ni ID ni título, mostramos un error
+	if title == "" {
 		app.render(w, r, "movie.html", &viewData{
 			Error: app.translator.T(lang, "error_require_id_title"),
 			Lang:  lang,
@@ -203,12 +475,31 @@ func (app *application) movieHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Con el título hay que buscar la película para conocer su ID
+	cachedMovie, err := app.movieModel.GetByTitle(r.Context(), title)
+	if err != nil {
+		app.render(w, r, "movie.html", &viewData{
+			Error: app.upstreamError(r, lang, "error_movie", err),
+			Lang:  lang,
+		})
+		return
+	}
+
+	if cachedMovie.Movie.ImdbID == "" {
+		app.renderMovie(w, r, cachedMovie)
+		return
+	}
+	http.Redirect(w, r, movieURL(cachedMovie.Movie.ImdbID, cachedMovie.Movie.Title), http.StatusMovedPermanently)
+}
+
+// renderMovie muestra la ficha de una película con las estadísticas de caché
+func (app *application) renderMovie(w http.ResponseWriter, r *http.Request, cachedMovie *models.CachedMovie) {
 	// Obtenemos las estadísticas de caché
 	hits, misses := app.movieModel.GetCacheStats()
 
 	data := &viewData{
 		Movie:      cachedMovie.Movie,
-		Lang:       lang,
+		Lang:       app.getLangFromRequest(r),
 		FromCache:  cachedMovie.FromCache,
 		CachedAt:   cachedMovie.CachedAt,
 		CacheHits:  hits,
@@ -217,25 +508,43 @@ func (app *application) movieHandler(w http.ResponseWriter, r *http.Request) {
 	app.render(w, r, "movie.html", data)
 }
 
+// Handler para las rutas que no existen
+func (app *application) notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	app.renderError(w, r, http.StatusNotFound, "error_not_found")
+}
+
 // Función para cargar las plantillas
-func loadTemplates(dir string) (map[string]*template.Template, error) {
+// fsys contiene layout.html y una plantilla por página
+func loadTemplates(fsys fs.FS) (map[string]*template.Template, error) {
 	templates := map[string]*template.Template{}
 	
 	// Crear el conjunto de funciones que estarán disponibles para las plantillas
 	// Nota: la función real "t" se añade en el momento de renderizar
 	funcMap := template.FuncMap{
 		"t": func(key string) string {
-			return key // Placeholder, será reemplazado en cada renderizado
+			return key // Placeholder, será reemplazado en la copia de cada idioma
+		},
+		"tf": func(key string, pairs ...any) (string, error) {
+			return key, nil
 		},
+		"tn": func(key string, n int, pairs ...any) (string, error) {
+			return key, nil
+		},
+		"languages": func() []language { return nil },
+		"slug":      textnorm.Slug,
+		"movieURL":  movieURL,
+	}
+	for name, fn := range formatFuncs("") {
+		funcMap[name] = fn
 	}
 
-	pages, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	pages, err := fs.Glob(fsys, "*.html")
 	if err != nil {
 		return nil, err
 	}
 
 	for _, page := range pages {
-		name := filepath.Base(page)
+		name := path.Base(page)
 		
 		// Saltar layout.html
 		if name == "layout.html" {
@@ -243,8 +552,8 @@ func loadTemplates(dir string) (map[string]*template.Template, error) {
 		}
 
 		// Crear un nuevo template con las funciones
-		ts, err := template.New("layout.html").Funcs(funcMap).ParseFiles(
-			filepath.Join(dir, "layout.html"),
+		ts, err := template.New("layout.html").Funcs(funcMap).ParseFS(fsys,
+			"layout.html",
 			page,
 		)
 		if err != nil {
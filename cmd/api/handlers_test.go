@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"html/template"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/prosales/go-api-movies/pkg/i18n"
@@ -14,16 +18,28 @@ import (
 
 // MockMovieModel es una implementación mock del modelo de películas para pruebas
 type MockMovieModel struct {
-	GetByTitleFunc   func(title string) (*models.CachedMovie, error)
-	SearchFunc       func(query string) (*omdb.SearchResult, error)
+	GetByTitleFunc    func(title string) (*models.CachedMovie, error)
+	GetByIDFunc       func(imdbID string) (*models.CachedMovie, error)
+	SearchFunc        func(query string) (*omdb.SearchResult, error)
 	GetCacheStatsFunc func() (hits, misses int)
+	CacheEntriesFunc  func(query string) []models.CacheEntry
+	EvictFunc         func(key string) bool
+	FlushFunc         func() int
+	WarmFunc          func(queries []string) []models.WarmResult
+	ExportFunc        func(w io.Writer) (int, error)
+	ImportFunc        func(r io.Reader, policy models.ImportPolicy) (models.ImportStats, error)
+	CacheStatsFunc    func() models.CacheStats
 }
 
-func (m *MockMovieModel) GetByTitle(title string) (*models.CachedMovie, error) {
+func (m *MockMovieModel) GetByTitle(ctx context.Context, title string) (*models.CachedMovie, error) {
 	return m.GetByTitleFunc(title)
 }
 
-func (m *MockMovieModel) Search(query string) (*omdb.SearchResult, error) {
+func (m *MockMovieModel) GetByID(ctx context.Context, imdbID string) (*models.CachedMovie, error) {
+	return m.GetByIDFunc(imdbID)
+}
+
+func (m *MockMovieModel) Search(ctx context.Context, query string) (*omdb.SearchResult, error) {
 	return m.SearchFunc(query)
 }
 
@@ -31,15 +47,74 @@ func (m *MockMovieModel) GetCacheStats() (hits, misses int) {
 	return m.GetCacheStatsFunc()
 }
 
+func (m *MockMovieModel) CacheEntries(query string) []models.CacheEntry {
+	return m.CacheEntriesFunc(query)
+}
+
+func (m *MockMovieModel) Evict(key string) bool {
+	return m.EvictFunc(key)
+}
+
+func (m *MockMovieModel) Flush() int {
+	return m.FlushFunc()
+}
+
+func (m *MockMovieModel) WarmWithOptions(ctx context.Context, queries []string, opts models.WarmOptions) []models.WarmResult {
+	results := m.WarmFunc(queries)
+	progress := models.WarmProgress{Total: len(queries)}
+	for _, result := range results {
+		progress.Done++
+		if result.Err != nil {
+			progress.Failed++
+		}
+		if opts.OnResult != nil {
+			opts.OnResult(result, progress)
+		}
+	}
+	return results
+}
+
+func (m *MockMovieModel) Export(w io.Writer) (int, error) {
+	return m.ExportFunc(w)
+}
+
+func (m *MockMovieModel) Import(r io.Reader, policy models.ImportPolicy) (models.ImportStats, error) {
+	return m.ImportFunc(r, policy)
+}
+
+func (m *MockMovieModel) CacheStats() models.CacheStats {
+	if m.CacheStatsFunc == nil {
+		return models.CacheStats{}
+	}
+	return m.CacheStatsFunc()
+}
+
 // MockTranslator es una implementación mock del traductor para pruebas
 type MockTranslator struct {
-	TFunc func(lang, key string) string
+	TFunc     func(lang, key string) string
+	Languages []string
 }
 
 func (m *MockTranslator) T(lang, key string) string {
 	return m.TFunc(lang, key)
 }
 
+func (m *MockTranslator) Tf(lang, key string, args i18n.Args) string {
+	return m.TFunc(lang, key)
+}
+
+func (m *MockTranslator) Tn(lang, key string, n int, args i18n.Args) string {
+	return m.TFunc(lang, key)
+}
+
+// AvailableLanguages devuelve Languages, o los idiomas de la aplicación si no se indicaron
+func (m *MockTranslator) AvailableLanguages() []string {
+	if m.Languages == nil {
+		return []string{"en", "es"}
+	}
+	return m.Languages
+}
+
 // Test para homeHandler
 func TestHomeHandler(t *testing.T) {
 	// Crear un traductor mock
@@ -48,7 +123,7 @@ func TestHomeHandler(t *testing.T) {
 			return key // Devuelve la clave como valor
 		},
 	}
-	
+
 	// Crear plantillas mock
 	tmpl, err := template.New("home.html").Parse("{{.Lang}}")
 	if err != nil {
@@ -57,21 +132,21 @@ func TestHomeHandler(t *testing.T) {
 	templates := map[string]*template.Template{
 		"home.html": tmpl,
 	}
-	
+
 	// Crear la aplicación con el modelo mock
 	app := &application{
 		translator:  mockTranslator,
 		templates:   templates,
 		defaultLang: "es",
 	}
-	
+
 	// Crear una solicitud HTTP mock
 	req := httptest.NewRequest("GET", "/", nil)
 	w := httptest.NewRecorder()
-	
+
 	// Llamar al handler
 	app.homeHandler(w, req)
-	
+
 	// Verificar el código de estado
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
@@ -86,7 +161,7 @@ func TestSearchHandler_EmptyQuery(t *testing.T) {
 			return key // Devuelve la clave como valor
 		},
 	}
-	
+
 	// Crear plantillas mock
 	tmpl, err := template.New("search.html").Parse("{{.Query}}")
 	if err != nil {
@@ -95,21 +170,21 @@ func TestSearchHandler_EmptyQuery(t *testing.T) {
 	templates := map[string]*template.Template{
 		"search.html": tmpl,
 	}
-	
+
 	// Crear la aplicación con el modelo mock
 	app := &application{
 		translator:  mockTranslator,
 		templates:   templates,
 		defaultLang: "es",
 	}
-	
+
 	// Crear una solicitud HTTP mock
 	req := httptest.NewRequest("GET", "/search", nil)
 	w := httptest.NewRecorder()
-	
+
 	// Llamar al handler
 	app.searchHandler(w, req)
-	
+
 	// Verificar el código de estado
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
@@ -138,14 +213,14 @@ func TestSearchHandler_WithQuery(t *testing.T) {
 			return 0, 0
 		},
 	}
-	
+
 	// Crear un traductor mock
 	mockTranslator := &MockTranslator{
 		TFunc: func(lang, key string) string {
 			return key // Devuelve la clave como valor
 		},
 	}
-	
+
 	// Crear plantillas mock
 	tmpl, err := template.New("search.html").Parse("{{.Query}}")
 	if err != nil {
@@ -154,30 +229,30 @@ func TestSearchHandler_WithQuery(t *testing.T) {
 	templates := map[string]*template.Template{
 		"search.html": tmpl,
 	}
-	
+
 	// Crear la aplicación con el modelo mock
 	app := &application{
-		movieModel: mockModel,
-		translator: mockTranslator,
-		templates:  templates,
+		movieModel:  mockModel,
+		translator:  mockTranslator,
+		templates:   templates,
 		defaultLang: "es",
 	}
-	
+
 	// Crear una solicitud HTTP mock
 	req := httptest.NewRequest("GET", "/search?query=test", nil)
 	w := httptest.NewRecorder()
-	
+
 	// Llamar al handler
 	app.searchHandler(w, req)
-	
+
 	// Verificar el código de estado
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 	}
 }
 
-// Test para movieHandler con título
-func TestMovieHandler_WithTitle(t *testing.T) {
+// Test para legacyMovieHandler con título: redirige a la URL canónica
+func TestLegacyMovieHandler_WithTitle(t *testing.T) {
 	// Crear un modelo mock
 	mockModel := &MockMovieModel{
 		GetByTitleFunc: func(title string) (*models.CachedMovie, error) {
@@ -186,6 +261,7 @@ func TestMovieHandler_WithTitle(t *testing.T) {
 				Movie: &omdb.Movie{
 					Title:    "Test Movie",
 					Year:     "2023",
+					ImdbID:   "tt1234567",
 					Director: "Test Director",
 					Plot:     "Test Plot",
 				},
@@ -197,14 +273,14 @@ func TestMovieHandler_WithTitle(t *testing.T) {
 			return 1, 0
 		},
 	}
-	
+
 	// Crear un traductor mock
 	mockTranslator := &MockTranslator{
 		TFunc: func(lang, key string) string {
 			return key // Devuelve la clave como valor
 		},
 	}
-	
+
 	// Crear plantillas mock
 	tmpl, err := template.New("movie.html").Parse("{{.Movie.Title}}")
 	if err != nil {
@@ -213,37 +289,40 @@ func TestMovieHandler_WithTitle(t *testing.T) {
 	templates := map[string]*template.Template{
 		"movie.html": tmpl,
 	}
-	
+
 	// Crear la aplicación con el modelo mock
 	app := &application{
-		movieModel: mockModel,
-		translator: mockTranslator,
-		templates:  templates,
+		movieModel:  mockModel,
+		translator:  mockTranslator,
+		templates:   templates,
 		defaultLang: "es",
 	}
-	
+
 	// Crear una solicitud HTTP mock
 	req := httptest.NewRequest("GET", "/movie?t=test", nil)
 	w := httptest.NewRecorder()
-	
+
 	// Llamar al handler
-	app.movieHandler(w, req)
-	
-	// Verificar el código de estado
-	if w.Code != http.StatusOK {
-		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	app.legacyMovieHandler(w, req)
+
+	// Verificar la redirección permanente
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("Expected status code %d, got %d", http.StatusMovedPermanently, w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/movie/tt1234567/test-movie" {
+		t.Errorf("Expected redirect to /movie/tt1234567/test-movie, got %s", location)
 	}
 }
 
-// Test para movieHandler sin título ni ID
-func TestMovieHandler_NoTitleOrID(t *testing.T) {
+// Test para legacyMovieHandler sin título ni ID
+func TestLegacyMovieHandler_NoTitleOrID(t *testing.T) {
 	// Crear un traductor mock
 	mockTranslator := &MockTranslator{
 		TFunc: func(lang, key string) string {
 			return key // Devuelve la clave como valor
 		},
 	}
-	
+
 	// Crear plantillas mock
 	tmpl, err := template.New("movie.html").Parse("{{.Error}}")
 	if err != nil {
@@ -252,47 +331,166 @@ func TestMovieHandler_NoTitleOrID(t *testing.T) {
 	templates := map[string]*template.Template{
 		"movie.html": tmpl,
 	}
-	
+
 	// Crear la aplicación con el modelo mock
 	app := &application{
-		translator: mockTranslator,
-		templates:  templates,
+		translator:  mockTranslator,
+		templates:   templates,
 		defaultLang: "es",
 	}
-	
+
 	// Crear una solicitud HTTP mock
 	req := httptest.NewRequest("GET", "/movie", nil)
 	w := httptest.NewRecorder()
-	
+
 	// Llamar al handler
-	app.movieHandler(w, req)
-	
+	app.legacyMovieHandler(w, req)
+
 	// Verificar el código de estado
 	if w.Code != http.StatusOK {
 		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
 	}
 }
 
+// Test para legacyMovieHandler con ID: redirige sin consultar la API
+func TestLegacyMovieHandler_WithID(t *testing.T) {
+	app := &application{defaultLang: "es"}
+
+	req := httptest.NewRequest("GET", "/movie?id=tt1234567", nil)
+	w := httptest.NewRecorder()
+	app.legacyMovieHandler(w, req)
+
+	if w.Code != http.StatusMovedPermanently {
+		t.Errorf("Expected status code %d, got %d", http.StatusMovedPermanently, w.Code)
+	}
+	if location := w.Header().Get("Location"); location != "/movie/tt1234567" {
+		t.Errorf("Expected redirect to /movie/tt1234567, got %s", location)
+	}
+}
+
+// newMovieApp crea una aplicación con un modelo que devuelve siempre la misma película
+func newMovieApp(t *testing.T) *application {
+	t.Helper()
+
+	mockModel := &MockMovieModel{
+		GetByIDFunc: func(imdbID string) (*models.CachedMovie, error) {
+			if imdbID != "tt1234567" {
+				return nil, omdb.ErrNotFound
+			}
+			return &models.CachedMovie{
+				Movie: &omdb.Movie{
+					Title:  "Amélie",
+					Year:   "2001",
+					ImdbID: "tt1234567",
+				},
+				CachedAt: time.Now(),
+			}, nil
+		},
+		GetCacheStatsFunc: func() (hits, misses int) {
+			return 0, 1
+		},
+	}
+
+	movieTmpl := template.Must(template.New("movie.html").Parse("{{if .Error}}{{.Error}}{{else}}{{.Movie.Title}}{{end}}"))
+	errorTmpl := template.Must(template.New("error.html").Parse("{{.Status}} {{.Error}}"))
+
+	return &application{
+		movieModel: mockModel,
+		translator: &MockTranslator{
+			TFunc: func(lang, key string) string { return key },
+		},
+		templates: map[string]*template.Template{
+			"movie.html": movieTmpl,
+			"error.html": errorTmpl,
+		},
+		defaultLang: "es",
+	}
+}
+
+// serveMovie atiende la petición con las rutas de películas del mux
+func serveMovie(app *application, target string) *httptest.ResponseRecorder {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /movie/{imdbID}", app.movieHandler)
+	mux.HandleFunc("GET /movie/{imdbID}/{slug}", app.movieHandler)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	return w
+}
+
+// Test para movieHandler con la URL canónica
+func TestMovieHandler_WithID(t *testing.T) {
+	w := serveMovie(newMovieApp(t), "/movie/tt1234567/amelie")
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if body := w.Body.String(); body != "Amélie" {
+		t.Errorf("Expected movie title in body, got %q", body)
+	}
+}
+
+// Test para movieHandler sin slug o con un slug antiguo: redirige a la URL canónica
+func TestMovieHandler_SlugRedirect(t *testing.T) {
+	for _, target := range []string{"/movie/tt1234567", "/movie/tt1234567/old-title"} {
+		w := serveMovie(newMovieApp(t), target)
+
+		if w.Code != http.StatusMovedPermanently {
+			t.Errorf("%s: expected status code %d, got %d", target, http.StatusMovedPermanently, w.Code)
+		}
+		if location := w.Header().Get("Location"); location != "/movie/tt1234567/amelie" {
+			t.Errorf("%s: expected redirect to /movie/tt1234567/amelie, got %s", target, location)
+		}
+	}
+}
+
+// Test para movieHandler con un ID no válido o inexistente
+func TestMovieHandler_NotFound(t *testing.T) {
+	w := serveMovie(newMovieApp(t), "/movie/not-an-id")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an invalid ID, got %d", http.StatusNotFound, w.Code)
+	}
+	if body := w.Body.String(); body != "404 error_not_found" {
+		t.Errorf("Expected error page, got %q", body)
+	}
+
+	w = serveMovie(newMovieApp(t), "/movie/tt7654321")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d for an unknown movie, got %d", http.StatusNotFound, w.Code)
+	}
+}
+
+// Test para movieURL
+func TestMovieURL(t *testing.T) {
+	if got := movieURL("tt0076759", "Star Wars: Episode IV"); got != "/movie/tt0076759/star-wars-episode-iv" {
+		t.Errorf("Unexpected URL %s", got)
+	}
+	if got := movieURL("tt0076759", ""); got != "/movie/tt0076759" {
+		t.Errorf("Unexpected URL without slug %s", got)
+	}
+}
+
 // Test para changeLangHandler
 func TestChangeLangHandler(t *testing.T) {
 	// Crear la aplicación
 	app := &application{
+		translator:  &MockTranslator{},
 		defaultLang: "es",
 	}
-	
+
 	// Crear una solicitud HTTP mock
 	req := httptest.NewRequest("GET", "/change-lang?lang=en", nil)
 	req.Header.Set("Referer", "/")
 	w := httptest.NewRecorder()
-	
+
 	// Llamar al handler
 	app.changeLangHandler(w, req)
-	
+
 	// Verificar el código de estado
 	if w.Code != http.StatusSeeOther {
 		t.Errorf("Expected status code %d, got %d", http.StatusSeeOther, w.Code)
 	}
-	
+
 	// Verificar que se establece la cookie
 	cookies := w.Result().Cookies()
 	foundCookie := false
@@ -305,7 +503,7 @@ func TestChangeLangHandler(t *testing.T) {
 	if !foundCookie {
 		t.Error("Expected lang cookie to be set, but it wasn't")
 	}
-	
+
 	// Verificar la redirección
 	location := w.Header().Get("Location")
 	if location != "/" {
@@ -317,19 +515,20 @@ func TestChangeLangHandler(t *testing.T) {
 func TestGetLangFromRequest_Cookie(t *testing.T) {
 	// Crear la aplicación
 	app := &application{
+		translator:  &MockTranslator{},
 		defaultLang: "es",
 	}
-	
+
 	// Crear una solicitud HTTP mock con cookie
 	req := httptest.NewRequest("GET", "/", nil)
 	req.AddCookie(&http.Cookie{
 		Name:  "lang",
 		Value: "en",
 	})
-	
+
 	// Obtener el idioma
 	lang := app.getLangFromRequest(req)
-	
+
 	// Verificar el idioma
 	if lang != "en" {
 		t.Errorf("Expected lang=en, got %s", lang)
@@ -340,18 +539,122 @@ func TestGetLangFromRequest_Cookie(t *testing.T) {
 func TestGetLangFromRequest_AcceptLanguage(t *testing.T) {
 	// Crear la aplicación
 	app := &application{
+		translator:  &MockTranslator{},
 		defaultLang: "es",
 	}
-	
+
 	// Crear una solicitud HTTP mock con cabecera Accept-Language
 	req := httptest.NewRequest("GET", "/", nil)
 	req.Header.Set("Accept-Language", "en-US,en;q=0.9")
-	
+
 	// Obtener el idioma
 	lang := app.getLangFromRequest(req)
-	
+
 	// Verificar el idioma
 	if lang != "en" {
 		t.Errorf("Expected lang=en, got %s", lang)
 	}
-} 
\ No newline at end of file
+}
+
+// Test para getLangFromRequest: negociación con pesos y región entre los
+// idiomas del traductor
+func TestGetLangFromRequest_Negotiation(t *testing.T) {
+	app := &application{
+		translator:  &MockTranslator{Languages: []string{"en", "es", "pt-BR"}},
+		defaultLang: "es",
+	}
+
+	tests := []struct {
+		query, cookie, header string
+		expected              string
+	}{
+		{header: "fr-FR, en;q=0.5, es;q=0.8", expected: "es"},
+		{header: "en-GB", expected: "en"},
+		{header: "pt-br;q=0.9, en;q=0.1", expected: "pt-BR"},
+		{header: "de, fr", expected: "es"},
+		{query: "es-MX", header: "en", expected: "es"},
+		{query: "fr", cookie: "en", expected: "en"},
+		{cookie: "xx", header: "en", expected: "en"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/?lang="+tt.query, nil)
+		if tt.cookie != "" {
+			req.AddCookie(&http.Cookie{Name: "lang", Value: tt.cookie})
+		}
+		req.Header.Set("Accept-Language", tt.header)
+		if got := app.getLangFromRequest(req); got != tt.expected {
+			t.Errorf("%+v: expected %s, got %s", tt, tt.expected, got)
+		}
+	}
+
+	// changeLangHandler solo guarda idiomas disponibles
+	w := httptest.NewRecorder()
+	app.changeLangHandler(w, httptest.NewRequest("GET", "/change-lang?lang=fr", nil))
+	if cookies := w.Result().Cookies(); len(cookies) != 1 || cookies[0].Value != "es" {
+		t.Errorf("Expected an unknown language to fall back to es, got %v", cookies)
+	}
+}
+
+// Test para render: las peticiones concurrentes en distintos idiomas no comparten
+// la función t (ejecutar con -race)
+func TestRender_ConcurrentLanguages(t *testing.T) {
+	translator, err := i18n.NewTranslator(fstest.MapFS{
+		"es/messages.json": {Data: []byte(`{"greeting": "Hola"}`)},
+		"en/messages.json": {Data: []byte(`{"greeting": "Hello"}`)},
+	}, "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, err := template.New("home.html").Funcs(template.FuncMap{
+		"t": func(key string) string { return key },
+	}).Parse(`{{t "greeting"}} {{.Lang}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &application{
+		translator:  translator,
+		templates:   map[string]*template.Template{"home.html": tmpl},
+		defaultLang: "es",
+	}
+
+	expected := map[string]string{
+		"es": "Hola es",
+		"en": "Hello en",
+		"fr": "Hola es", // Un idioma sin traducciones se sirve en el predeterminado
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		for lang, want := range expected {
+			wg.Add(1)
+			go func(lang, want string) {
+				defer wg.Done()
+				w := httptest.NewRecorder()
+				app.homeHandler(w, httptest.NewRequest("GET", "/?lang="+lang, nil))
+				if got := w.Body.String(); got != want {
+					t.Errorf("lang=%s: expected %q, got %q", lang, want, got)
+				}
+			}(lang, want)
+		}
+	}
+	wg.Wait()
+
+	// Solo se crean copias para los idiomas que tiene el traductor
+	if len(app.langTemplates) != 2 {
+		t.Errorf("Expected template copies for 2 languages, got %d", len(app.langTemplates))
+	}
+}
+
+// Test para templateArgs: pares de nombre y valor de tf y tn
+func TestTemplateArgs(t *testing.T) {
+	args, err := templateArgs([]any{"query", "alien", "n", 3})
+	if err != nil || args["query"] != "alien" || args["n"] != 3 {
+		t.Errorf("Expected the named arguments, got %v, %v", args, err)
+	}
+	if _, err := templateArgs([]any{"query"}); err == nil {
+		t.Error("Expected an error for an odd number of arguments")
+	}
+	if _, err := templateArgs([]any{1, "alien"}); err == nil {
+		t.Error("Expected an error for a name that is not a string")
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// version se inyecta al compilar con -ldflags "-X main.version=..."
+var version = "dev"
+
+// Tiempo máximo que puede tardar cada comprobación de disponibilidad
+const readinessCheckTimeout = 2 * time.Second
+
+// Páginas que deben estar cargadas para atender peticiones
+var requiredTemplates = []string{"home.html", "search.html", "movie.html", "error.html", "admin.html"}
+
+// pinger lo implementan los componentes que pueden comprobar su estado
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// circuitChecker lo implementan los clientes con cortocircuito, como el de OMDB
+type circuitChecker interface {
+	CheckCircuit(ctx context.Context) error
+}
+
+// checkResult es el resultado de una comprobación individual
+type checkResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// healthResponse es la respuesta de /healthz y /readyz
+type healthResponse struct {
+	Status string                 `json:"status"`
+	Checks map[string]checkResult `json:"checks,omitempty"`
+	Warmup *warmupStatus          `json:"warmup,omitempty"`
+}
+
+// versionResponse es la respuesta de /version
+type versionResponse struct {
+	Version   string    `json:"version"`
+	Revision  string    `json:"revision,omitempty"`
+	BuildTime string    `json:"build_time,omitempty"`
+	Modified  bool      `json:"modified,omitempty"`
+	GoVersion string    `json:"go_version"`
+	StartedAt time.Time `json:"started_at"`
+	Uptime    string    `json:"uptime"`
+}
+
+// Handler de vida: solo indica que el proceso responde
+func (app *application) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// Handler de disponibilidad: comprueba que la aplicación puede atender peticiones.
+// La comprobación de OMDB solo se hace con ?upstream=1 o con --ready-upstream;
+// la de su cortocircuito se hace siempre, porque no llama a OMDB.
+// Si hay precarga de arranque se muestra su progreso, y con --warm-block-ready
+// la aplicación no está disponible hasta que termine.
+func (app *application) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]func(ctx context.Context) error{
+		"templates": app.checkTemplates,
+		"locales":   app.checkLocales,
+		"cache":     app.checkCache,
+	}
+	if c, ok := app.upstream.(circuitChecker); ok {
+		checks["circuit"] = c.CheckCircuit
+	}
+	if app.upstream != nil && (app.readyUpstream || r.URL.Query().Get("upstream") == "1") {
+		checks["upstream"] = app.upstream.Ping
+	}
+	if app.warmup != nil && app.warmBlocksReady {
+		checks["warmup"] = app.checkWarmup
+	}
+
+	resp := healthResponse{
+		Status: "ok",
+		Checks: make(map[string]checkResult, len(checks)),
+	}
+	for name, check := range checks {
+		result := runCheck(r.Context(), check)
+		if result.Status != "ok" {
+			resp.Status = "fail"
+		}
+		resp.Checks[name] = result
+	}
+	if app.warmup != nil {
+		status := app.warmup.snapshot()
+		resp.Warmup = &status
+	}
+
+	status := http.StatusOK
+	if resp.Status != "ok" {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, resp)
+}
+
+// Handler con la versión y los datos de compilación
+func (app *application) versionHandler(w http.ResponseWriter, r *http.Request) {
+	resp := versionResponse{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		StartedAt: app.startedAt,
+		Uptime:    time.Since(app.startedAt).Truncate(time.Second).String(),
+	}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				resp.Revision = s.Value
+			case "vcs.time":
+				resp.BuildTime = s.Value
+			case "vcs.modified":
+				resp.Modified = s.Value == "true"
+			}
+		}
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// runCheck ejecuta una comprobación con tiempo límite y mide su latencia
+func runCheck(ctx context.Context, check func(ctx context.Context) error) checkResult {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := checkResult{
+		Status:    "ok",
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = "fail"
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkTemplates verifica que las plantillas de todas las páginas están cargadas
+func (app *application) checkTemplates(ctx context.Context) error {
+	for _, name := range requiredTemplates {
+		if !app.hasTemplate(name) {
+			return fmt.Errorf("plantilla %s no cargada", name)
+		}
+	}
+	return nil
+}
+
+// checkLocales verifica que se cargó al menos un idioma, incluido el predeterminado
+func (app *application) checkLocales(ctx context.Context) error {
+	if app.translator == nil {
+		return errors.New("traductor no inicializado")
+	}
+
+	langs := app.translator.AvailableLanguages()
+	if len(langs) == 0 {
+		return errors.New("no se cargó ningún idioma")
+	}
+	for _, lang := range langs {
+		if lang == app.defaultLang {
+			return nil
+		}
+	}
+	return fmt.Errorf("idioma predeterminado %s no cargado", app.defaultLang)
+}
+
+// checkCache verifica que la caché de películas responde
+func (app *application) checkCache(ctx context.Context) error {
+	if app.movieModel == nil {
+		return errors.New("modelo de películas no inicializado")
+	}
+
+	if p, ok := app.movieModel.(pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// mockPinger es un componente mock con comprobación de estado
+type mockPinger struct {
+	err    error
+	called bool
+}
+
+func (p *mockPinger) Ping(ctx context.Context) error {
+	p.called = true
+	return p.err
+}
+
+// newReadyApp crea una aplicación con todas las dependencias de /readyz
+func newReadyApp(t *testing.T) *application {
+	t.Helper()
+
+	templates := map[string]*template.Template{}
+	for _, name := range requiredTemplates {
+		templates[name] = template.Must(template.New(name).Parse(""))
+	}
+
+	return &application{
+		movieModel: &MockMovieModel{},
+		templates:  templates,
+		translator: &MockTranslator{
+			TFunc: func(lang, key string) string { return key },
+		},
+		defaultLang: "es",
+		startedAt:   time.Now(),
+	}
+}
+
+// decodeHealth decodifica la respuesta JSON de un endpoint de salud
+func decodeHealth(t *testing.T, w *httptest.ResponseRecorder) healthResponse {
+	t.Helper()
+
+	var resp healthResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Error al decodificar la respuesta: %v", err)
+	}
+	return resp
+}
+
+// Test para healthzHandler
+func TestHealthzHandler(t *testing.T) {
+	app := &application{}
+
+	req := httptest.NewRequest("GET", "/healthz", nil)
+	w := httptest.NewRecorder()
+	app.healthzHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+	if resp := decodeHealth(t, w); resp.Status != "ok" {
+		t.Errorf("Expected status ok, got %s", resp.Status)
+	}
+}
+
+// Test para readyzHandler con todo cargado
+func TestReadyzHandler_Ready(t *testing.T) {
+	upstream := &mockPinger{}
+	app := newReadyApp(t)
+	app.upstream = upstream
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+	app.readyzHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	resp := decodeHealth(t, w)
+	for _, name := range []string{"templates", "locales", "cache"} {
+		if resp.Checks[name].Status != "ok" {
+			t.Errorf("Expected check %s to be ok, got %+v", name, resp.Checks[name])
+		}
+	}
+
+	// La comprobación de OMDB es opcional y no debe ejecutarse por defecto
+	if _, ok := resp.Checks["upstream"]; ok || upstream.called {
+		t.Error("Expected upstream check to be skipped by default")
+	}
+}
+
+// Test para readyzHandler sin plantillas
+func TestReadyzHandler_MissingTemplate(t *testing.T) {
+	app := newReadyApp(t)
+	delete(app.templates, "movie.html")
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+	app.readyzHandler(w, req)
+
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+
+	resp := decodeHealth(t, w)
+	if resp.Status != "fail" {
+		t.Errorf("Expected status fail, got %s", resp.Status)
+	}
+	if resp.Checks["templates"].Error == "" {
+		t.Error("Expected templates check to report an error")
+	}
+}
+
+// Test para readyzHandler con la comprobación de OMDB solicitada
+func TestReadyzHandler_Upstream(t *testing.T) {
+	upstream := &mockPinger{err: errors.New("connection refused")}
+	app := newReadyApp(t)
+	app.upstream = upstream
+
+	req := httptest.NewRequest("GET", "/readyz?upstream=1", nil)
+	w := httptest.NewRecorder()
+	app.readyzHandler(w, req)
+
+	if !upstream.called {
+		t.Error("Expected upstream check to run")
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if resp := decodeHealth(t, w); resp.Checks["upstream"].Status != "fail" {
+		t.Errorf("Expected upstream check to fail, got %+v", resp.Checks["upstream"])
+	}
+}
+
+// mockCircuitPinger es un cliente mock con cortocircuito
+type mockCircuitPinger struct {
+	mockPinger
+	circuitErr error
+}
+
+func (p *mockCircuitPinger) CheckCircuit(ctx context.Context) error {
+	return p.circuitErr
+}
+
+// Test para readyzHandler con el cortocircuito de OMDB abierto: falla sin llamar a OMDB
+func TestReadyzHandler_CircuitOpen(t *testing.T) {
+	upstream := &mockCircuitPinger{circuitErr: errors.New("circuito de OMDB abierto")}
+	app := newReadyApp(t)
+	app.upstream = upstream
+
+	req := httptest.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+	app.readyzHandler(w, req)
+
+	if upstream.called {
+		t.Error("Expected OMDB not to be called")
+	}
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status code %d, got %d", http.StatusServiceUnavailable, w.Code)
+	}
+	if resp := decodeHealth(t, w); resp.Checks["circuit"].Status != "fail" {
+		t.Errorf("Expected circuit check to fail, got %+v", resp.Checks["circuit"])
+	}
+
+	upstream.circuitErr = nil
+	w = httptest.NewRecorder()
+	app.readyzHandler(w, req)
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d with the circuit closed, got %d", http.StatusOK, w.Code)
+	}
+}
+
+// Test para versionHandler
+func TestVersionHandler(t *testing.T) {
+	app := &application{startedAt: time.Now()}
+
+	req := httptest.NewRequest("GET", "/version", nil)
+	w := httptest.NewRecorder()
+	app.versionHandler(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, w.Code)
+	}
+
+	var resp versionResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("Error al decodificar la respuesta: %v", err)
+	}
+	if resp.Version != version {
+		t.Errorf("Expected version %s, got %s", version, resp.Version)
+	}
+	if resp.GoVersion == "" {
+		t.Error("Expected go_version to be set")
+	}
+}
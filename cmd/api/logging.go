@@ -0,0 +1,72 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/logging"
+)
+
+// Cabecera con la que se recibe y se devuelve el identificador de petición
+const requestIDHeader = "X-Request-ID"
+
+// Longitud máxima aceptada para un X-Request-ID recibido del cliente
+const maxRequestIDLength = 128
+
+// requestID asigna a cada petición un identificador, reutilizando el que envía
+// el cliente o el proxy si es válido, y lo guarda en el contexto y en la respuesta
+func requestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		ctx := logging.WithRequestID(r.Context(), id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// validRequestID acepta identificadores cortos de caracteres ASCII visibles
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID genera un identificador aleatorio de 16 bytes en hexadecimal
+func newRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// accessLog escribe un registro estructurado por cada petición atendida
+func accessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		slog.InfoContext(r.Context(), "http request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"route", r.Pattern,
+			"status", rw.statusCode(),
+			"bytes", rw.bytes,
+			"duration", time.Since(start),
+			"remote_addr", r.RemoteAddr,
+			"user_agent", r.UserAgent(),
+		)
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prosales/go-api-movies/pkg/logging"
+)
+
+// Test para requestID: genera un identificador si no se recibe ninguno
+func TestRequestID_Generated(t *testing.T) {
+	var seen string
+	handler := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	if len(seen) != 32 {
+		t.Errorf("Expected a 32-character generated request ID, got %q", seen)
+	}
+	if got := w.Header().Get(requestIDHeader); got != seen {
+		t.Errorf("Expected response header %q, got %q", seen, got)
+	}
+}
+
+// Test para requestID: propaga el identificador recibido y descarta los no válidos
+func TestRequestID_Propagated(t *testing.T) {
+	var seen string
+	handler := requestID(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = logging.RequestID(r.Context())
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(requestIDHeader, "upstream-42")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != "upstream-42" {
+		t.Errorf("Expected propagated request ID upstream-42, got %q", seen)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(requestIDHeader, "bad id\nwith newline")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if seen == "bad id\nwith newline" {
+		t.Error("Expected invalid request ID to be replaced")
+	}
+}
+
+// Test para accessLog: el registro incluye ruta, estado e identificador de petición
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := logging.NewHandler(&buf, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := slog.Default()
+	slog.SetDefault(slog.New(h))
+	defer slog.SetDefault(previous)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/search", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := requestID(accessLog(mux))
+
+	req := httptest.NewRequest("GET", "/search?query=x", nil)
+	req.Header.Set(requestIDHeader, "req-7")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	out := buf.String()
+	for _, want := range []string{`"msg":"http request"`, `"route":"/search"`, `"status":404`, `"request_id":"req-7"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected access log to contain %s, got %s", want, out)
+		}
+	}
+}
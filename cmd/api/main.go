@@ -1,48 +1,138 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
-	"log"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 
+	assets "github.com/prosales/go-api-movies"
 	"github.com/prosales/go-api-movies/pkg/i18n"
+	"github.com/prosales/go-api-movies/pkg/logging"
 	"github.com/prosales/go-api-movies/pkg/models"
+	"github.com/prosales/go-api-movies/pkg/omdb"
+	"github.com/prosales/go-api-movies/pkg/peers"
 )
 
 func main() {
-	// Definir flags para la línea de comandos
-	addr := flag.String("addr", ":8080", "Dirección HTTP")
-	apiKey := flag.String("apikey", "", "API Key para OMDB")
-	templateDir := flag.String("templates", "./templates", "Ruta a las plantillas")
-	staticDir := flag.String("static", "./static", "Ruta a los archivos estáticos")
-	localesDir := flag.String("locales", "./locales", "Ruta a los archivos de traducción")
-	defaultLang := flag.String("lang", "es", "Idioma predeterminado (es, en)")
-	flag.Parse()
-
-	// Verificar que se proporcionó una API key
-	if *apiKey == "" {
-		// Intentar obtener la API key de una variable de entorno
-		*apiKey = os.Getenv("OMDB_API_KEY")
-		if *apiKey == "" {
-			log.Fatal("OMDB API key no proporcionada. Use --apikey o la variable de entorno OMDB_API_KEY")
+	// Subcomandos que trabajan contra un servidor en marcha o sobre la caché en disco
+	if len(os.Args) > 1 && (snapshotCommands[os.Args[1]] || os.Args[1] == "migrate") {
+		var err error
+		if os.Args[1] == "migrate" {
+			err = runMigrateCommand(context.Background(), os.Args[2:], os.LookupEnv, os.Stdout, os.Stderr)
+		} else {
+			err = runSnapshotCommand(os.Args[1], os.Args[2:], os.LookupEnv, os.Stdin, os.Stdout, os.Stderr)
 		}
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Cargar la configuración: valores por defecto, archivo, entorno y flags
+	cfg, err := loadConfig(os.Args[1:], os.LookupEnv, os.Stderr)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	if cfg.PrintConfig {
+		// Se muestra antes de validar para poder revisar una configuración incorrecta
+		if err := cfg.printConfig(os.Stdout); err != nil {
+			fatal("error printing configuration", "error", err)
+		}
+	}
+	if err := cfg.validate(); err != nil {
+		fatal("invalid configuration", "error", err)
+	}
+	if cfg.PrintConfig {
+		return
 	}
 
+	// Configurar el logger estructurado
+	level, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		fatal("invalid log configuration", "error", err)
+	}
+	handler, err := logging.NewHandler(os.Stderr, cfg.LogFormat, level)
+	if err != nil {
+		fatal("invalid log configuration", "error", err)
+	}
+	slog.SetDefault(slog.New(handler))
+
 	// Inicializar el modelo de películas
-	movieModel := models.NewMovieModel(*apiKey)
+	// El mismo cliente de OMDB sirve al modelo y a /readyz, que informa de su cortocircuito
+	client := omdb.NewClient(cfg.APIKey)
+	cacheOpts := models.CacheOptions{
+		Client:        client,
+		MaxBytes:      cfg.CacheMaxBytes,
+		CompressAfter: cfg.CacheCompressAfter,
+		L1TTL:         cfg.CacheL1TTL,
+		L2TTL:         cfg.CacheL2TTL,
+		WriteBack:     cfg.CacheWriteBack,
+	}
+	if cfg.CacheDir != "" {
+		disk, err := models.NewDiskBackend(cfg.CacheDir)
+		if err != nil {
+			fatal("error opening cache directory", "dir", cfg.CacheDir, "error", err)
+		}
+		cacheOpts.L2 = disk
+	}
+	if cfg.Peers != "" {
+		cacheOpts.Peers = peers.NewPool(cfg.PeerSelf, splitList(cfg.Peers), cfg.PeerSecret)
+	}
+	movieModel := models.NewMovieModelWithOptions(cfg.APIKey, cacheOpts)
 
 	// Cargar plantillas
-	templates, err := loadTemplates(*templateDir)
-	if err != nil {
-		log.Fatal(err)
+	// Los recursos embebidos se pueden sustituir por directorios en disco durante el desarrollo
+	if cfg.Dev {
+		// Los recursos embebidos no cambian: en modo desarrollo se leen del disco
+		cfg.TemplateDir = defaultDir(cfg.TemplateDir, "templates")
+		cfg.StaticDir = defaultDir(cfg.StaticDir, "static")
+		cfg.LocalesDir = defaultDir(cfg.LocalesDir, "locales")
 	}
+	templateFS := assetFS(cfg.TemplateDir, assets.Templates)
+	staticFS := assetFS(cfg.StaticDir, assets.Static)
+	localesFS := assetFS(cfg.LocalesDir, assets.Locales)
 
-	// Inicializar el traductor
-	translator, err := i18n.NewTranslator(*localesDir, *defaultLang)
+	templates, templateErr := loadTemplates(templateFS)
+	if templateErr != nil && !cfg.Dev {
+		fatal("error loading templates", "error", templateErr)
+	}
+
+	// Inicializar el traductor. En modo desarrollo se arranca sin traducciones
+	// y el error se muestra en el navegador hasta que se corrija.
+	translator, localesErr := i18n.NewTranslator(localesFS, cfg.DefaultLang)
+	if localesErr != nil {
+		if !cfg.Dev {
+			fatal("error initializing translator", "error", localesErr)
+		}
+		translator = i18n.NewEmptyTranslator(localesFS, cfg.DefaultLang)
+	}
+
+	proxies, err := parseCIDRs(splitList(cfg.TrustedProxies))
 	if err != nil {
-		log.Fatalf("Error al inicializar el traductor: %v", err)
+		fatal("invalid trusted proxies", "error", err)
+	}
+	tokens := make(map[string]bool)
+	for _, token := range splitList(cfg.APITokens) {
+		tokens[token] = true
 	}
 
 	// Inicializar la aplicación
@@ -50,29 +140,170 @@ func main() {
 		movieModel:  movieModel,
 		templates:   templates,
 		translator:  translator,
-		defaultLang: *defaultLang,
-	}
+		defaultLang: cfg.DefaultLang,
 
-	// Configurar el gestor de archivos estáticos
-	fileServer := http.FileServer(http.Dir(*staticDir))
-	http.Handle("/static/", http.StripPrefix("/static", fileServer))
+		upstream:      client,
+		readyUpstream: cfg.ReadyUpstream,
+		startedAt:     time.Now(),
+		corsOrigins:   splitList(cfg.CORSOrigins),
 
-	// Configurar las rutas
-	http.HandleFunc("/", app.homeHandler)
-	http.HandleFunc("/search", app.searchHandler)
-	http.HandleFunc("/movie", app.movieHandler)
-	http.HandleFunc("/change-lang", app.changeLangHandler)
+		upstreamLimiter: newRateLimiter("upstream", cfg.UpstreamRate, cfg.UpstreamBurst),
+		defaultLimiter:  newRateLimiter("default", cfg.DefaultRate, cfg.DefaultBurst),
+		trustedProxies:  proxies,
+		apiTokens:       tokens,
+
+		adminUser:     cfg.AdminUser,
+		adminPassword: cfg.AdminPassword,
+
+		warmOptions: models.WarmOptions{
+			Concurrency: cfg.WarmConcurrency,
+			Rate:        cfg.WarmRate,
+		},
+
+		dev: cfg.Dev,
+	}
+	if cfg.Peers != "" {
+		app.peerLoader = movieModel.LoadForPeer
+		app.peerSecret = cfg.PeerSecret
+	}
+	if templateErr != nil {
+		// Solo en modo desarrollo: se muestra en el navegador hasta que se corrija
+		slog.Error("error loading templates", "error", templateErr)
+		app.setDevError("templates", templateErr)
+	}
+	if localesErr != nil {
+		slog.Error("error initializing translator", "error", localesErr)
+		app.setDevError("locales", localesErr)
+	}
 
 	// Iniciar el servidor
-	log.Printf("Iniciando servidor en %s", *addr)
-	log.Printf("Usando API key: %s", hideApiKey(*apiKey))
-	log.Printf("Directorio de plantillas: %s", filepath.Clean(*templateDir))
-	log.Printf("Directorio de archivos estáticos: %s", filepath.Clean(*staticDir))
-	log.Printf("Directorio de traducciones: %s", filepath.Clean(*localesDir))
-	log.Printf("Idioma predeterminado: %s", *defaultLang)
-
-	err = http.ListenAndServe(*addr, nil)
-	log.Fatal(err)
+	slog.Info("starting server",
+		"addr", cfg.Addr,
+		"tls", cfg.TLSCert != "",
+		"http_redirect_addr", cfg.HTTPRedirect,
+		"config", cfg.ConfigFile,
+		"api_key", hideApiKey(cfg.APIKey),
+		"templates", assetSource(cfg.TemplateDir),
+		"static", assetSource(cfg.StaticDir),
+		"locales", assetSource(cfg.LocalesDir),
+		"default_lang", cfg.DefaultLang,
+		"dev", cfg.Dev,
+		"admin", cfg.AdminPassword != "",
+		"peers", len(splitList(cfg.Peers)),
+	)
+
+	srv := &http.Server{
+		Addr:         cfg.Addr,
+		Handler:      app.routes(staticFS),
+		ReadTimeout:  cfg.ReadTimeout,
+		WriteTimeout: cfg.WriteTimeout,
+		IdleTimeout:  cfg.IdleTimeout,
+		ErrorLog:     slog.NewLogLogger(handler, slog.LevelError),
+	}
+
+	// Apagar de forma ordenada al recibir SIGINT o SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	app.baseCtx = ctx
+
+	if cfg.TLSCert != "" {
+		certs, err := newCertReloader(cfg.TLSCert, cfg.TLSKey)
+		if err != nil {
+			fatal("error loading TLS certificate", "error", err)
+		}
+		configureTLS(srv, certs, cfg.HTTP2)
+		go certs.reloadOnSIGHUP(ctx)
+	}
+
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		fatal("error opening listener", "addr", cfg.Addr, "error", err)
+	}
+
+	// Listener HTTP opcional que solo redirige a HTTPS
+	var redirectWG sync.WaitGroup
+	if cfg.HTTPRedirect != "" {
+		redirectLn, err := net.Listen("tcp", cfg.HTTPRedirect)
+		if err != nil {
+			fatal("error opening listener", "addr", cfg.HTTPRedirect, "error", err)
+		}
+		redirectSrv := &http.Server{
+			Handler:      redirectHTTPS(cfg.Addr),
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+			ErrorLog:     srv.ErrorLog,
+		}
+		redirectWG.Add(1)
+		go func() {
+			defer redirectWG.Done()
+			if err := runServer(ctx, redirectSrv, redirectLn, cfg.DrainTimeout); err != nil {
+				slog.Error("redirect server exited with error", "error", err)
+			}
+		}()
+	}
+
+	// Precargar la caché en segundo plano
+	if cfg.WarmFile != "" {
+		queries, err := readWarmList(cfg.WarmFile)
+		if err != nil {
+			fatal("error reading warm-up list", "error", err)
+		}
+		app.warmup = newWarmup(len(queries))
+		app.warmBlocksReady = cfg.WarmBlockReady
+		go runWarmup(ctx, app.warmup, movieModel, queries, app.warmOptions)
+	}
+
+	if cfg.Dev {
+		go app.watchTemplates(ctx, templateFS)
+		go app.watchLocales(ctx, translator)
+	}
+
+	if err := app.serve(ctx, srv, ln, cfg.DrainTimeout); err != nil {
+		fatal("server exited with error", "error", err)
+	}
+	redirectWG.Wait()
+}
+
+// assetFS devuelve el directorio dir si se indicó, o los recursos embebidos si no
+func assetFS(dir string, embedded fs.FS) fs.FS {
+	if dir == "" {
+		return embedded
+	}
+	return os.DirFS(dir)
+}
+
+// defaultDir devuelve dir si se indicó, o fallback en caso contrario
+func defaultDir(dir, fallback string) string {
+	if dir == "" {
+		return fallback
+	}
+	return dir
+}
+
+// assetSource describe de dónde se cargan unos recursos, para los logs
+func assetSource(dir string) string {
+	if dir == "" {
+		return "embedded"
+	}
+	return filepath.Clean(dir)
+}
+
+// splitList separa una lista de valores separados por comas, ignorando los vacíos
+func splitList(s string) []string {
+	var values []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// fatal registra un error y termina el proceso
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
 
 // hideApiKey oculta parte de la API key para imprimirla en los logs
@@ -1,11 +1,13 @@
 package main
 
 import (
+	"io/fs"
 	"net/http"
 	"net/http/httptest"
-	"path/filepath"
+	"os"
 	"testing"
 
+	assets "github.com/prosales/go-api-movies"
 	"github.com/prosales/go-api-movies/pkg/i18n"
 	"github.com/prosales/go-api-movies/pkg/models"
 )
@@ -72,7 +74,7 @@ func TestIntegration(t *testing.T) {
 	// Configurar la aplicación para los tests
 	t.Run("Application_Setup", func(t *testing.T) {
 		// Configurar el traductor
-		translator, err := i18n.NewTranslator("../../locales", "es")
+		translator, err := i18n.NewTranslator(os.DirFS("../../locales"), "es")
 		if err != nil {
 			t.Fatalf("Error al configurar el traductor: %v", err)
 		}
@@ -81,7 +83,7 @@ func TestIntegration(t *testing.T) {
 		movieModel := models.NewMovieModel("test_api_key")
 		
 		// Cargar las plantillas
-		templates, err := loadTemplates("../../templates")
+		templates, err := loadTemplates(os.DirFS("../../templates"))
 		if err != nil {
 			t.Fatalf("Error al cargar las plantillas: %v", err)
 		}
@@ -94,15 +96,11 @@ func TestIntegration(t *testing.T) {
 			defaultLang: "es",
 		}
 		
-		// Configurar los manejadores HTTP
-		mux := http.NewServeMux()
-		mux.HandleFunc("/", app.homeHandler)
-		mux.HandleFunc("/search", app.searchHandler)
-		mux.HandleFunc("/movie", app.movieHandler)
-		mux.HandleFunc("/change-lang", app.changeLangHandler)
+		// Configurar los manejadores HTTP con la cadena de middlewares
+		handler := app.routes(os.DirFS("../../static"))
 		
 		// Crear un servidor de prueba
-		testServer := httptest.NewServer(mux)
+		testServer := httptest.NewServer(handler)
 		defer testServer.Close()
 		
 		// Probar la página principal
@@ -115,12 +113,41 @@ func TestIntegration(t *testing.T) {
 		if resp.StatusCode != http.StatusOK {
 			t.Errorf("La página principal devolvió un código de estado incorrecto: %d", resp.StatusCode)
 		}
+		if resp.Header.Get("Content-Security-Policy") == "" {
+			t.Error("La página principal no incluye la cabecera Content-Security-Policy")
+		}
+		if resp.Header.Get(requestIDHeader) == "" {
+			t.Error("La página principal no incluye la cabecera X-Request-ID")
+		}
+
+		// Las rutas desconocidas muestran la página de error
+		notFound, err := http.Get(testServer.URL + "/no-existe")
+		if err != nil {
+			t.Fatalf("Error al acceder a una ruta desconocida: %v", err)
+		}
+		notFound.Body.Close()
+		if notFound.StatusCode != http.StatusNotFound {
+			t.Errorf("Una ruta desconocida devolvió %d en lugar de 404", notFound.StatusCode)
+		}
+
+		// Los archivos estáticos de texto se sirven comprimidos
+		req, _ := http.NewRequest("GET", testServer.URL+"/static/css/style.css", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		staticResp, err := http.DefaultTransport.RoundTrip(req)
+		if err != nil {
+			t.Fatalf("Error al acceder a los archivos estáticos: %v", err)
+		}
+		defer staticResp.Body.Close()
+
+		if staticResp.Header.Get("Content-Encoding") != "gzip" {
+			t.Errorf("Se esperaba la hoja de estilos comprimida con gzip, se obtuvo %q", staticResp.Header.Get("Content-Encoding"))
+		}
 	})
 }
 
 // TestLoadTemplates prueba la función loadTemplates
 func TestLoadTemplates(t *testing.T) {
-	templates, err := loadTemplates("../../templates")
+	templates, err := loadTemplates(os.DirFS("../../templates"))
 	if err != nil {
 		t.Fatalf("Error al cargar las plantillas: %v", err)
 	}
@@ -132,4 +159,29 @@ func TestLoadTemplates(t *testing.T) {
 			t.Errorf("No se cargó la plantilla %s", name)
 		}
 	}
-} 
\ No newline at end of file
+}
+
+// TestEmbeddedAssets prueba que el binario incluye plantillas, traducciones y estáticos
+func TestEmbeddedAssets(t *testing.T) {
+	templates, err := loadTemplates(assets.Templates)
+	if err != nil {
+		t.Fatalf("Error al cargar las plantillas embebidas: %v", err)
+	}
+	for _, name := range requiredTemplates {
+		if _, ok := templates[name]; !ok {
+			t.Errorf("No se cargó la plantilla embebida %s", name)
+		}
+	}
+
+	translator, err := i18n.NewTranslator(assets.Locales, "es")
+	if err != nil {
+		t.Fatalf("Error al cargar las traducciones embebidas: %v", err)
+	}
+	if got := translator.T("en", "home"); got != "Home" {
+		t.Errorf("Se esperaba la traducción embebida Home, se obtuvo %s", got)
+	}
+
+	if _, err := fs.Stat(assets.Static, "css/style.css"); err != nil {
+		t.Errorf("No se encontró la hoja de estilos embebida: %v", err)
+	}
+}
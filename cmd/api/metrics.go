@@ -0,0 +1,48 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/metrics"
+)
+
+// Métricas de las peticiones HTTP
+var (
+	httpRequestsTotal = metrics.NewCounterVec(
+		"movies_http_requests_total",
+		"Peticiones HTTP atendidas por ruta, método y código de estado.",
+		"route", "method", "status",
+	)
+	httpRequestDuration = metrics.NewHistogramVec(
+		"movies_http_request_duration_seconds",
+		"Latencia de las peticiones HTTP por ruta y método.",
+		nil,
+		"route", "method",
+	)
+	httpRequestsInFlight = metrics.NewGaugeVec(
+		"movies_http_requests_in_flight",
+		"Peticiones HTTP en curso.",
+	)
+)
+
+// instrument registra las métricas HTTP de cada petición. La ruta se toma del
+// patrón con el que el mux resolvió la petición, para no crear una serie por URL.
+func instrument(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		start := time.Now()
+		rw := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rw, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		httpRequestsTotal.Inc(route, r.Method, strconv.Itoa(rw.statusCode()))
+		httpRequestDuration.Observe(time.Since(start).Seconds(), route, r.Method)
+	})
+}
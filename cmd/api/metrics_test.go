@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Test para instrument: las métricas usan el patrón de la ruta, no la URL
+func TestInstrument(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/instrumented/", func(w http.ResponseWriter, r *http.Request) {
+		if httpRequestsInFlight.Value() < 1 {
+			t.Error("Expected request to be counted as in flight")
+		}
+		w.WriteHeader(http.StatusTeapot)
+	})
+	handler := instrument(mux)
+
+	before := httpRequestsTotal.Value("/instrumented/", "GET", "418")
+	for _, path := range []string{"/instrumented/a", "/instrumented/b"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	}
+
+	if got := httpRequestsTotal.Value("/instrumented/", "GET", "418") - before; got != 2 {
+		t.Errorf("Expected 2 requests recorded for the route, got %v", got)
+	}
+	if httpRequestDuration.Count("/instrumented/", "GET") < 2 {
+		t.Error("Expected request latency to be observed")
+	}
+	if v := httpRequestsInFlight.Value(); v != 0 {
+		t.Errorf("Expected no requests in flight, got %v", v)
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// middleware envuelve un handler para añadirle comportamiento
+type middleware func(http.Handler) http.Handler
+
+// chain aplica los middlewares a h; el primero de la lista es el más externo
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+// responseRecorder guarda el código de estado y los bytes escritos de una respuesta
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rw *responseRecorder) WriteHeader(status int) {
+	if rw.status == 0 {
+		rw.status = status
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *responseRecorder) Write(b []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
+}
+
+// Unwrap permite a http.ResponseController llegar al ResponseWriter original
+func (rw *responseRecorder) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// statusCode devuelve el código enviado, o 200 si el handler no escribió nada
+func (rw *responseRecorder) statusCode() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+// recoverPanic captura los pánicos de los handlers, los registra y muestra la
+// página de error traducida si todavía no se había empezado a responder
+func (app *application) recoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			err := recover()
+			if err == nil {
+				return
+			}
+			// ErrAbortHandler es la forma acordada de cortar una respuesta
+			if err == http.ErrAbortHandler {
+				panic(err)
+			}
+
+			slog.ErrorContext(r.Context(), "panic recovered",
+				"error", err,
+				"stack", string(debug.Stack()),
+			)
+
+			if rw.status != 0 {
+				return
+			}
+			rw.Header().Del("Content-Encoding")
+			rw.Header().Del("Content-Length")
+			rw.Header().Set("Connection", "close")
+			app.renderError(rw, r, http.StatusInternalServerError, "error_internal")
+		}()
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// contentSecurityPolicy permite los recursos propios y los de los CDN usados en layout.html
+var contentSecurityPolicy = strings.Join([]string{
+	"default-src 'self'",
+	"script-src 'self' https://cdn.jsdelivr.net",
+	"style-src 'self' https://cdn.jsdelivr.net https://fonts.googleapis.com",
+	"font-src 'self' https://cdn.jsdelivr.net https://fonts.gstatic.com",
+	"img-src 'self' https: data:",
+	"connect-src 'self'",
+	"object-src 'none'",
+	"base-uri 'self'",
+	"form-action 'self'",
+	"frame-ancestors 'none'",
+}, "; ")
+
+// securityHeaders añade las cabeceras de seguridad a todas las respuestas
+func (app *application) securityHeaders(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Content-Security-Policy", contentSecurityPolicy)
+		h.Set("X-Frame-Options", "DENY")
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+
+		// Los navegadores ignoran HSTS sobre HTTP, así que solo se envía sobre TLS.
+		// X-Forwarded-Proto solo cuenta si lo envía un proxy de confianza.
+		if r.TLS != nil || (app.fromTrustedProxy(r) && r.Header.Get("X-Forwarded-Proto") == "https") {
+			h.Set("Strict-Transport-Security", "max-age=31536000; includeSubDomains")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cors permite que los orígenes indicados consuman la API JSON desde el navegador.
+// Un origen "*" permite cualquiera; una lista vacía desactiva CORS.
+func cors(origins []string) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			h.Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			allowed := origin != "" && (slices.Contains(origins, "*") || slices.Contains(origins, origin))
+			if allowed {
+				if slices.Contains(origins, "*") {
+					h.Set("Access-Control-Allow-Origin", "*")
+				} else {
+					h.Set("Access-Control-Allow-Origin", origin)
+				}
+				h.Set("Access-Control-Expose-Headers", requestIDHeader)
+			}
+
+			// Responder a las peticiones preflight sin llegar al handler
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				if allowed {
+					h.Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+					h.Set("Access-Control-Allow-Headers", "Authorization, Content-Type, "+requestIDHeader)
+					h.Set("Access-Control-Max-Age", "600")
+				}
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Tamaño mínimo para que merezca la pena comprimir una respuesta de tamaño conocido
+const minCompressSize = 1024
+
+// encoder es un compresor reutilizable
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// encodings son las codificaciones soportadas, en orden de preferencia del servidor
+var encodings = []struct {
+	name string
+	pool *sync.Pool
+}{
+	{"gzip", &sync.Pool{New: func() any {
+		return gzip.NewWriter(io.Discard)
+	}}},
+	{"deflate", &sync.Pool{New: func() any {
+		w, _ := flate.NewWriter(io.Discard, flate.DefaultCompression)
+		return w
+	}}},
+}
+
+// negotiateEncoding elige la codificación a partir de Accept-Encoding, respetando
+// los pesos q. Devuelve "" si el cliente no acepta ninguna de las soportadas.
+func negotiateEncoding(header string) string {
+	weights := map[string]float64{}
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		weights[name] = parseQuality(params)
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range encodings {
+		q, ok := weights[enc.name]
+		if !ok {
+			q, ok = weights["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = enc.name, q
+		}
+	}
+	return best
+}
+
+// parseQuality extrae el valor q de los parámetros de una entrada; por defecto vale 1
+func parseQuality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+		if !ok || strings.TrimSpace(key) != "q" {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil || q < 0 {
+			return 0
+		}
+		return min(q, 1)
+	}
+	return 1
+}
+
+// compressible indica si merece la pena comprimir un tipo de contenido
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/json", mediaType == "application/javascript",
+		mediaType == "application/xml", mediaType == "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+// compress comprime las respuestas de texto con la codificación que acepte el cliente
+func compress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		name := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if name == "" || r.Header.Get("Range") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: name}
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter decide en la primera escritura si comprime la respuesta
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	enc      encoder
+	pool     *sync.Pool
+	decided  bool
+}
+
+// decide activa la compresión si el estado, el tipo y el tamaño lo permiten
+func (cw *compressWriter) decide(status int) {
+	cw.decided = true
+
+	h := cw.Header()
+	if status < 200 || status == http.StatusNoContent || status == http.StatusNotModified ||
+		status == http.StatusPartialContent || h.Get("Content-Encoding") != "" ||
+		!compressible(h.Get("Content-Type")) {
+		return
+	}
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < minCompressSize {
+		return
+	}
+
+	for _, e := range encodings {
+		if e.name == cw.encoding {
+			cw.pool = e.pool
+			cw.enc = e.pool.Get().(encoder)
+			cw.enc.Reset(cw.ResponseWriter)
+		}
+	}
+	h.Set("Content-Encoding", cw.encoding)
+	h.Del("Content-Length")
+}
+
+func (cw *compressWriter) WriteHeader(status int) {
+	if !cw.decided {
+		cw.decide(status)
+	}
+	cw.ResponseWriter.WriteHeader(status)
+}
+
+func (cw *compressWriter) Write(b []byte) (int, error) {
+	if !cw.decided {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		cw.WriteHeader(http.StatusOK)
+	}
+	if cw.enc == nil {
+		return cw.ResponseWriter.Write(b)
+	}
+	return cw.enc.Write(b)
+}
+
+// Flush vacía el compresor antes de vaciar la conexión
+func (cw *compressWriter) Flush() {
+	if cw.enc != nil {
+		cw.enc.Flush()
+	}
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Unwrap permite a http.ResponseController llegar al ResponseWriter original
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// close termina el flujo comprimido y devuelve el compresor al pool
+func (cw *compressWriter) close() {
+	if cw.enc == nil {
+		return
+	}
+	cw.enc.Close()
+	cw.enc.Reset(io.Discard)
+	cw.pool.Put(cw.enc)
+	cw.enc = nil
+}
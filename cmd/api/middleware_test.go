@@ -0,0 +1,266 @@
+package main
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"html/template"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Test para chain: los middlewares se aplican en el orden indicado
+func TestChain(t *testing.T) {
+	var order []string
+	mark := func(name string) middleware {
+		return func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				order = append(order, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	handler := chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		order = append(order, "handler")
+	}), mark("a"), mark("b"))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	if got := strings.Join(order, ","); got != "a,b,handler" {
+		t.Errorf("Expected order a,b,handler, got %s", got)
+	}
+}
+
+// Test para recoverPanic: muestra la página de error traducida
+func TestRecoverPanic(t *testing.T) {
+	tmpl, err := template.New("error.html").Parse("{{.Status}} {{.Error}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &application{
+		translator: &MockTranslator{
+			TFunc: func(lang, key string) string { return lang + ":" + key },
+		},
+		templates:   map[string]*template.Template{"error.html": tmpl},
+		defaultLang: "es",
+	}
+
+	handler := app.recoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))
+
+	req := httptest.NewRequest("GET", "/?lang=en", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, got %d", http.StatusInternalServerError, w.Code)
+	}
+	if body := w.Body.String(); body != "500 en:error_internal" {
+		t.Errorf("Expected translated error page, got %q", body)
+	}
+}
+
+// Test para recoverPanic: http.ErrAbortHandler se propaga
+func TestRecoverPanic_AbortHandler(t *testing.T) {
+	app := &application{}
+	handler := app.recoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Error("Expected http.ErrAbortHandler to be re-panicked")
+		}
+	}()
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+}
+
+// Test para securityHeaders
+func TestSecurityHeaders(t *testing.T) {
+	proxies, err := parseCIDRs([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &application{trustedProxies: proxies}
+	handler := app.securityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+
+	csp := w.Header().Get("Content-Security-Policy")
+	for _, want := range []string{"https://cdn.jsdelivr.net", "https://fonts.googleapis.com", "https://fonts.gstatic.com", "frame-ancestors 'none'"} {
+		if !strings.Contains(csp, want) {
+			t.Errorf("Expected CSP to contain %s, got %s", want, csp)
+		}
+	}
+	if w.Header().Get("X-Frame-Options") != "DENY" {
+		t.Errorf("Expected X-Frame-Options=DENY, got %s", w.Header().Get("X-Frame-Options"))
+	}
+	if w.Header().Get("Strict-Transport-Security") != "" {
+		t.Error("Expected no HSTS header over plain HTTP")
+	}
+
+	// X-Forwarded-Proto solo cuenta si viene de un proxy de confianza
+	tests := []struct {
+		name       string
+		remoteAddr string
+		tls        bool
+		hsts       bool
+	}{
+		{"untrusted client", "203.0.113.7:1234", false, false},
+		{"trusted proxy", "10.0.0.1:1234", false, true},
+		{"direct TLS", "203.0.113.7:1234", true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.tls {
+				req.TLS = &tls.ConnectionState{}
+			} else {
+				req.Header.Set("X-Forwarded-Proto", "https")
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if got := w.Header().Get("Strict-Transport-Security") != ""; got != tt.hsts {
+				t.Errorf("Expected HSTS %v, got %v", tt.hsts, got)
+			}
+		})
+	}
+}
+
+// Test para cors con un origen permitido y otro no
+func TestCORS(t *testing.T) {
+	handler := cors([]string{"https://app.example.com"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+
+	req := httptest.NewRequest("GET", "/version", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Expected allowed origin to be echoed, got %q", got)
+	}
+
+	req = httptest.NewRequest("GET", "/version", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Expected no CORS header for a foreign origin, got %q", got)
+	}
+}
+
+// Test para cors: las peticiones preflight se responden sin llegar al handler
+func TestCORS_Preflight(t *testing.T) {
+	handler := cors([]string{"*"})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Preflight request should not reach the handler")
+	}))
+
+	req := httptest.NewRequest("OPTIONS", "/readyz", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("Expected status code %d, got %d", http.StatusNoContent, w.Code)
+	}
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Expected wildcard origin, got %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if w.Header().Get("Access-Control-Allow-Methods") == "" {
+		t.Error("Expected Access-Control-Allow-Methods to be set")
+	}
+}
+
+// Test para negotiateEncoding
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"br, gzip;q=0.5, deflate;q=0.8", "deflate"},
+		{"gzip;q=0, deflate", "deflate"},
+		{"br", ""},
+		{"*", "gzip"},
+		{"*;q=0.5, gzip;q=0", "deflate"},
+		{"identity", ""},
+	}
+
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.expected {
+			t.Errorf("negotiateEncoding(%q) = %q, expected %q", tt.header, got, tt.expected)
+		}
+	}
+}
+
+// Test para compress: las respuestas de texto grandes se comprimen
+func TestCompress(t *testing.T) {
+	body := strings.Repeat("<p>Star Wars</p>", 200)
+	handler := compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte(body))
+	}))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+
+	if w.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("Expected gzip encoding, got %q", w.Header().Get("Content-Encoding"))
+	}
+	if !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+		t.Error("Expected Vary: Accept-Encoding")
+	}
+
+	zr, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(decoded) != body {
+		t.Error("Decompressed body does not match the original")
+	}
+}
+
+// Test para compress: no se comprimen tipos binarios ni respuestas pequeñas
+func TestCompress_Skipped(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		length      string
+	}{
+		{"binary", "image/png", ""},
+		{"small", "text/css", "100"},
+	}
+
+	for _, tt := range tests {
+		handler := compress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", tt.contentType)
+			if tt.length != "" {
+				w.Header().Set("Content-Length", tt.length)
+			}
+			w.Write([]byte(strings.Repeat("x", 100)))
+		}))
+
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		if w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("%s: expected no compression, got %q", tt.name, w.Header().Get("Content-Encoding"))
+		}
+	}
+}
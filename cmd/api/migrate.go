@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/prosales/go-api-movies/pkg/models"
+)
+
+// runMigrateCommand ejecuta el subcomando migrate, que convierte la caché en
+// disco al esquema actual sin esperar a que se lea cada película. Conviene
+// ejecutarlo con el servidor parado, después de actualizar la aplicación.
+func runMigrateCommand(ctx context.Context, args []string, lookupEnv func(string) (string, bool), stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dir := fs.String("cache-dir", envOr(lookupEnv, "CACHE_DIR", ""), "Directorio de la caché en disco")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Uso: movies migrate --cache-dir DIRECTORIO")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New("falta el directorio de la caché (--cache-dir o MOVIES_CACHE_DIR)")
+	}
+
+	disk, err := models.NewDiskBackend(*dir)
+	if err != nil {
+		return err
+	}
+	stats, err := disk.Migrate(ctx)
+	fmt.Fprintf(stdout, "%d películas migradas, %d al día, %d más recientes, %d en cuarentena\n",
+		stats.Migrated, stats.Current, stats.Newer, stats.Quarantined)
+	return err
+}
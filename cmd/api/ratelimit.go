@@ -0,0 +1,210 @@
+package main
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/metrics"
+)
+
+// Cada cuánto se eliminan los buckets inactivos
+const rateLimitSweepInterval = time.Minute
+
+// Peticiones rechazadas por el limitador
+var httpRateLimitedTotal = metrics.NewCounterVec(
+	"movies_http_rate_limited_total",
+	"Peticiones rechazadas por superar el límite de tasa, por clase de ruta.",
+	"class",
+)
+
+// tokenBucket es el estado del limitador para un cliente
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// rateLimiter limita las peticiones por cliente con un token bucket: cada
+// cliente dispone de burst peticiones que se recargan a razón de rate por segundo
+type rateLimiter struct {
+	class string
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// newRateLimiter crea un limitador; devuelve nil (sin límite) si rate no es positivo
+func newRateLimiter(class string, rate float64, burst int) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		class:   class,
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// allow consume un token del cliente. Si no quedan, indica cuánto hay que esperar.
+func (l *rateLimiter) allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	// Recargar los tokens acumulados desde la última petición
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	return false, wait
+}
+
+// sweep elimina los buckets que ya se han recargado por completo; un cliente
+// nuevo empezaría con el mismo estado, así que no hace falta conservarlos
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimit aplica el limitador a las peticiones y responde 429 con Retry-After
+// y la página de error traducida cuando un cliente lo supera
+func (app *application) rateLimit(l *rateLimiter) middleware {
+	return func(next http.Handler) http.Handler {
+		if l == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, wait := l.allow(app.clientKey(r))
+			if !ok {
+				httpRateLimitedTotal.Inc(l.class)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				app.renderError(w, r, http.StatusTooManyRequests, "error_rate_limited")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifica al cliente para el limitador: por token de API si envía
+// uno conocido, o por su dirección IP en caso contrario
+func (app *application) clientKey(r *http.Request) string {
+	if token := requestToken(r); token != "" && app.apiTokens[token] {
+		return "token:" + token
+	}
+	return "ip:" + app.clientIP(r)
+}
+
+// requestToken extrae el token de API de Authorization: Bearer o de X-API-Token
+func requestToken(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if scheme, token, ok := strings.Cut(auth, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get("X-API-Token"))
+}
+
+// clientIP devuelve la IP del cliente. X-Forwarded-For solo se tiene en cuenta
+// si la conexión viene de un proxy de confianza; en ese caso se recorre de
+// derecha a izquierda y se toma la primera dirección que no sea de confianza.
+func (app *application) clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !app.trustedProxy(ip) {
+		return host
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := net.ParseIP(strings.TrimSpace(hops[i]))
+		if hop == nil {
+			// Una entrada no válida no es fiable: quedarse con el último salto conocido
+			break
+		}
+		ip = hop
+		if !app.trustedProxy(hop) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+// fromTrustedProxy indica si la conexión viene de un proxy de confianza, de modo
+// que se puede creer lo que diga en las cabeceras X-Forwarded-*
+func (app *application) fromTrustedProxy(r *http.Request) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && app.trustedProxy(ip)
+}
+
+// trustedProxy indica si ip pertenece a alguna de las redes de proxies de confianza
+func (app *application) trustedProxy(ip net.IP) bool {
+	for _, network := range app.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseCIDRs convierte una lista de redes o direcciones IP sueltas en redes
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	var networks []*net.IPNet
+	for _, v := range values {
+		if !strings.Contains(v, "/") {
+			if ip := net.ParseIP(v); ip != nil && ip.To4() != nil {
+				v += "/32"
+			} else {
+				v += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/peers"
+)
+
+// newTestLimiter crea un limitador con un reloj controlado por el test
+func newTestLimiter(rate float64, burst int) (*rateLimiter, *time.Time) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	l := newRateLimiter("test", rate, burst)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+// Test para rateLimiter: ráfaga, rechazo y recarga
+func TestRateLimiter_Allow(t *testing.T) {
+	l, now := newTestLimiter(1, 3)
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("a"); !ok {
+			t.Fatalf("Expected request %d within the burst to be allowed", i+1)
+		}
+	}
+
+	ok, wait := l.allow("a")
+	if ok {
+		t.Fatal("Expected request over the burst to be rejected")
+	}
+	if wait <= 0 || wait > time.Second {
+		t.Errorf("Expected a wait of up to 1s, got %s", wait)
+	}
+
+	// Otro cliente tiene su propio bucket
+	if ok, _ := l.allow("b"); !ok {
+		t.Error("Expected a different client to be allowed")
+	}
+
+	// Tras un segundo se recarga un token
+	*now = now.Add(time.Second)
+	if ok, _ := l.allow("a"); !ok {
+		t.Error("Expected request to be allowed after the refill")
+	}
+}
+
+// Test para rateLimiter: los buckets recargados se eliminan
+func TestRateLimiter_Sweep(t *testing.T) {
+	l, now := newTestLimiter(1, 2)
+	l.allow("a")
+
+	*now = now.Add(2 * rateLimitSweepInterval)
+	l.allow("b")
+
+	if _, ok := l.buckets["a"]; ok {
+		t.Error("Expected idle bucket to be swept")
+	}
+	if _, ok := l.buckets["b"]; !ok {
+		t.Error("Expected active bucket to be kept")
+	}
+}
+
+// Test para newRateLimiter: una tasa de cero desactiva el límite
+func TestNewRateLimiter_Disabled(t *testing.T) {
+	if l := newRateLimiter("test", 0, 10); l != nil {
+		t.Error("Expected nil limiter for a zero rate")
+	}
+
+	app := &application{}
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	if h := app.rateLimit(nil)(next); h == nil {
+		t.Error("Expected handler to be returned unchanged")
+	}
+}
+
+// Test para rateLimit: responde 429 con Retry-After y la página traducida
+func TestRateLimit_TooManyRequests(t *testing.T) {
+	tmpl, err := template.New("error.html").Parse("{{.Status}} {{.Error}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, _ := newTestLimiter(0.5, 1)
+	app := &application{
+		translator: &MockTranslator{
+			TFunc: func(lang, key string) string { return key },
+		},
+		templates:   map[string]*template.Template{"error.html": tmpl},
+		defaultLang: "es",
+	}
+	handler := app.rateLimit(l)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	req := httptest.NewRequest("GET", "/search?query=x", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected first request to succeed, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status code %d, got %d", http.StatusTooManyRequests, w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After=2, got %q", got)
+	}
+	if body := w.Body.String(); body != "429 error_rate_limited" {
+		t.Errorf("Expected translated error page, got %q", body)
+	}
+}
+
+// Test para peerHandler: las réplicas con el secreto no tienen límite y el
+// resto pasa por el limitador antes de comprobar el secreto
+func TestPeerHandler_RateLimit(t *testing.T) {
+	tmpl, err := template.New("error.html").Parse("{{.Status}} {{.Error}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, _ := newTestLimiter(0.5, 1)
+	app := &application{
+		translator: &MockTranslator{
+			TFunc: func(lang, key string) string { return key },
+		},
+		templates:   map[string]*template.Template{"error.html": tmpl},
+		defaultLang: "es",
+		peerLoader: func(ctx context.Context, key, query string) ([]byte, error) {
+			return []byte(`{}`), nil
+		},
+		peerSecret: "secret",
+	}
+	handler := app.peerHandler(app.rateLimit(l))
+
+	for i := 0; i < 5; i++ {
+		req := httptest.NewRequest("GET", peers.Path+"?key=k", nil)
+		req.Header.Set("X-Peer-Secret", "secret")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("Expected authenticated request %d to succeed, got %d", i+1, w.Code)
+		}
+	}
+
+	codes := make([]int, 2)
+	for i := range codes {
+		req := httptest.NewRequest("GET", peers.Path+"?key=k", nil)
+		req.Header.Set("X-Peer-Secret", "wrong")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		codes[i] = w.Code
+	}
+	if codes[0] != http.StatusForbidden || codes[1] != http.StatusTooManyRequests {
+		t.Errorf("Expected codes [403 429] without the secret, got %v", codes)
+	}
+}
+
+// Test para clientIP con y sin proxies de confianza
+func TestClientIP(t *testing.T) {
+	proxies, err := parseCIDRs([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	app := &application{trustedProxies: proxies}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		expected   string
+	}{
+		{"direct", "203.0.113.5:1234", "", "203.0.113.5"},
+		{"untrusted proxy ignored", "203.0.113.5:1234", "198.51.100.7", "203.0.113.5"},
+		{"trusted proxy", "10.0.0.2:1234", "198.51.100.7", "198.51.100.7"},
+		{"proxy chain", "10.0.0.2:1234", "198.51.100.7, 192.168.1.1", "198.51.100.7"},
+		{"spoofed leftmost entry", "10.0.0.2:1234", "1.1.1.1, 198.51.100.7", "198.51.100.7"},
+		{"all trusted", "10.0.0.2:1234", "10.0.0.3", "10.0.0.3"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tt.remoteAddr
+		if tt.forwarded != "" {
+			req.Header.Set("X-Forwarded-For", tt.forwarded)
+		}
+		if got := app.clientIP(req); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.name, tt.expected, got)
+		}
+	}
+}
+
+// Test para clientKey: solo los tokens conocidos tienen límite propio
+func TestClientKey(t *testing.T) {
+	app := &application{apiTokens: map[string]bool{"secret": true}}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "203.0.113.5:1234"
+	req.Header.Set("Authorization", "Bearer secret")
+	if got := app.clientKey(req); got != "token:secret" {
+		t.Errorf("Expected token key, got %s", got)
+	}
+
+	req.Header.Set("Authorization", "Bearer unknown")
+	if got := app.clientKey(req); got != "ip:203.0.113.5" {
+		t.Errorf("Expected unknown token to fall back to the IP, got %s", got)
+	}
+
+	req.Header.Del("Authorization")
+	req.Header.Set("X-API-Token", "secret")
+	if got := app.clientKey(req); got != "token:secret" {
+		t.Errorf("Expected X-API-Token to be accepted, got %s", got)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"io/fs"
+	"net/http"
+
+	"github.com/prosales/go-api-movies/pkg/metrics"
+	"github.com/prosales/go-api-movies/pkg/peers"
+)
+
+// routes registra las rutas de la aplicación y devuelve el handler con la
+// cadena de middlewares aplicada
+func (app *application) routes(static fs.FS) http.Handler {
+	mux := http.NewServeMux()
+
+	// Las rutas que consultan OMDB tienen un límite más estricto que las que
+	// solo sirven contenido local o en caché
+	upstream := app.rateLimit(app.upstreamLimiter)
+	local := app.rateLimit(app.defaultLimiter)
+
+	// Configurar el gestor de archivos estáticos
+	fileServer := http.FileServerFS(static)
+	mux.Handle("GET /static/", local(http.StripPrefix("/static", fileServer)))
+
+	// Páginas
+	mux.Handle("GET /{$}", local(http.HandlerFunc(app.homeHandler)))
+	mux.Handle("GET /search", upstream(http.HandlerFunc(app.searchHandler)))
+	mux.Handle("GET /movie/{imdbID}", upstream(http.HandlerFunc(app.movieHandler)))
+	mux.Handle("GET /movie/{imdbID}/{slug}", upstream(http.HandlerFunc(app.movieHandler)))
+	mux.Handle("GET /movie", upstream(http.HandlerFunc(app.legacyMovieHandler)))
+	mux.Handle("GET /change-lang", local(http.HandlerFunc(app.changeLangHandler)))
+	mux.Handle("/", local(http.HandlerFunc(app.notFoundHandler)))
+
+	// Administración de la caché, solo si se configuró una contraseña
+	if app.adminPassword != "" {
+		admin := func(h http.HandlerFunc) http.Handler {
+			return local(app.requireAdmin(h))
+		}
+		mux.Handle("GET /admin", admin(app.adminHandler))
+		mux.Handle("POST /admin/evict", admin(app.adminEvictHandler))
+		mux.Handle("POST /admin/flush", admin(app.adminFlushHandler))
+		mux.Handle("POST /admin/warm", admin(app.adminWarmHandler))
+		mux.Handle("GET /admin/warm", admin(app.adminWarmStatusHandler))
+		mux.Handle("GET /admin/export", admin(app.adminExportHandler))
+		mux.Handle("POST /admin/import", admin(app.adminImportHandler))
+	}
+
+	// Peticiones de caché de otras réplicas. Las que traen el secreto no tienen
+	// límite, porque cada réplica reúne el tráfico de muchos clientes que ya
+	// limita ella; el resto pasa por el límite estricto antes del rechazo.
+	if app.peerLoader != nil {
+		mux.Handle("GET "+peers.Path, app.peerHandler(upstream))
+	}
+
+	// API JSON y operación; OPTIONS llega al middleware CORS para el preflight
+	api := cors(app.corsOrigins)
+	handleAPI := func(path string, h http.HandlerFunc) {
+		mux.Handle("GET "+path, api(h))
+		mux.Handle("OPTIONS "+path, api(h))
+	}
+	handleAPI("/healthz", app.healthzHandler)
+	handleAPI("/readyz", app.readyzHandler)
+	handleAPI("/version", app.versionHandler)
+	mux.Handle("GET /metrics", metrics.Default.Handler())
+
+	return chain(mux,
+		requestID,
+		accessLog,
+		instrument,
+		app.recoverPanic,
+		app.securityHeaders,
+		compress,
+	)
+}
+
+// peerHandler atiende a las demás réplicas: sin límite si la petición trae el
+// secreto compartido y con limit si no
+func (app *application) peerHandler(limit middleware) http.Handler {
+	h := peers.Handler(app.peerLoader, app.peerSecret)
+	limited := limit(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if peers.Authenticated(r, app.peerSecret) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		limited.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Valores predeterminados del servidor HTTP
+const (
+	defaultReadTimeout  = 10 * time.Second
+	defaultWriteTimeout = 30 * time.Second
+	defaultIdleTimeout  = 120 * time.Second
+	defaultDrainTimeout = 15 * time.Second
+)
+
+// serve atiende peticiones en ln hasta que ctx se cancela. Al cancelarse deja de
+// aceptar conexiones nuevas, espera como máximo drain a que terminen las
+// peticiones en curso y finalmente vuelca el estado persistente de la aplicación.
+func (app *application) serve(ctx context.Context, srv *http.Server, ln net.Listener, drain time.Duration) error {
+	err := runServer(ctx, srv, ln, drain)
+	app.flush()
+	return err
+}
+
+// runServer atiende peticiones en ln hasta que ctx se cancela y después apaga
+// srv de forma ordenada. Si srv tiene configuración TLS, sirve HTTPS.
+func runServer(ctx context.Context, srv *http.Server, ln net.Listener, drain time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if srv.TLSConfig != nil {
+			// El certificado lo proporciona TLSConfig.GetCertificate
+			errCh <- srv.ServeTLS(ln, "", "")
+			return
+		}
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		// El servidor terminó por sí mismo (por ejemplo, error en el listener)
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutdown signal received, draining requests", "addr", ln.Addr().String(), "drain_timeout", drain)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		slog.Warn("forced shutdown with requests still in flight", "error", err)
+		srv.Close()
+	}
+
+	// Serve devuelve ErrServerClosed en cuanto empieza Shutdown
+	if serveErr := <-errCh; serveErr != nil && !errors.Is(serveErr, http.ErrServerClosed) {
+		err = errors.Join(err, serveErr)
+	}
+
+	slog.Info("server stopped", "addr", ln.Addr().String())
+	return err
+}
+
+// flush guarda el estado que deba sobrevivir al proceso antes de salir
+func (app *application) flush() {
+	if app.movieModel == nil {
+		return
+	}
+
+	hits, misses := app.movieModel.GetCacheStats()
+	slog.Info("final cache stats", "hits", hits, "misses", misses)
+
+	// Los modelos con almacenamiento persistente implementan io.Closer
+	if c, ok := app.movieModel.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			slog.Error("error closing movie model", "error", err)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// closableModel añade io.Closer al modelo mock para comprobar el volcado al apagar
+type closableModel struct {
+	*MockMovieModel
+	closed bool
+}
+
+func (m *closableModel) Close() error {
+	m.closed = true
+	return nil
+}
+
+// Test para serve: las peticiones en curso terminan antes de apagar
+func TestServe_GracefulShutdown(t *testing.T) {
+	model := &closableModel{
+		MockMovieModel: &MockMovieModel{
+			GetCacheStatsFunc: func() (hits, misses int) {
+				return 0, 0
+			},
+		},
+	}
+	app := &application{movieModel: model}
+
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("ok"))
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: mux}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- app.serve(ctx, srv, ln, 5*time.Second)
+	}()
+
+	// Lanzar una petición lenta y cancelar mientras está en curso
+	respCh := make(chan string, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String() + "/slow")
+		if err != nil {
+			respCh <- "error: " + err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		respCh <- string(body)
+	}()
+
+	<-started
+	cancel()
+
+	if body := <-respCh; body != "ok" {
+		t.Errorf("Expected in-flight request to complete with body ok, got %q", body)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Expected no error from serve, got %v", err)
+	}
+	if !model.closed {
+		t.Error("Expected model to be closed on shutdown")
+	}
+}
+
+// Test para serve: el tiempo de drenaje limita la espera
+func TestServe_DrainTimeout(t *testing.T) {
+	app := &application{}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stuck", func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: mux}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- app.serve(ctx, srv, ln, 50*time.Millisecond)
+	}()
+
+	go http.Get("http://" + ln.Addr().String() + "/stuck")
+	<-started
+	cancel()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Error("Expected an error when the drain timeout expires, got nil")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("serve did not return after the drain timeout")
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/models"
+)
+
+// Subcomandos para guardar y restaurar la caché de un servidor en marcha a
+// través de /admin/export y /admin/import
+var snapshotCommands = map[string]bool{"export": true, "import": true}
+
+// snapshotClient habla con el área de administración de un servidor
+type snapshotClient struct {
+	baseURL  string
+	user     string
+	password string
+	client   *http.Client
+}
+
+// runSnapshotCommand ejecuta el subcomando export o import. Las credenciales
+// se toman de los flags o de MOVIES_ADMIN_USER y MOVIES_ADMIN_PASSWORD.
+func runSnapshotCommand(name string, args []string, lookupEnv func(string) (string, bool), stdin io.Reader, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+
+	sc := &snapshotClient{client: &http.Client{Timeout: 5 * time.Minute}}
+	fs.StringVar(&sc.baseURL, "url", envOr(lookupEnv, "URL", "http://localhost:8080"), "URL del servidor")
+	fs.StringVar(&sc.user, "admin-user", envOr(lookupEnv, "ADMIN_USER", "admin"), "Usuario del área de administración")
+	fs.StringVar(&sc.password, "admin-password", envOr(lookupEnv, "ADMIN_PASSWORD", ""), "Contraseña del área de administración")
+
+	var out, policy string
+	switch name {
+	case "export":
+		fs.StringVar(&out, "out", "-", "Archivo donde guardar la instantánea (- para la salida estándar)")
+		fs.Usage = func() {
+			fmt.Fprintln(stderr, "Uso: movies export [--url URL] [--out ARCHIVO]")
+			fs.PrintDefaults()
+		}
+	case "import":
+		fs.StringVar(&policy, "policy", string(models.ImportNewer), "Qué hacer con las películas ya cacheadas: skip, overwrite o newer")
+		fs.Usage = func() {
+			fmt.Fprintln(stderr, "Uso: movies import [--url URL] [--policy POLÍTICA] ARCHIVO")
+			fs.PrintDefaults()
+		}
+	default:
+		return fmt.Errorf("subcomando %q desconocido", name)
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if sc.password == "" {
+		return errors.New("falta la contraseña de administración (--admin-password o MOVIES_ADMIN_PASSWORD)")
+	}
+
+	if name == "export" {
+		n, err := sc.export(out, stdout)
+		if err != nil {
+			return err
+		}
+		if out != "-" {
+			fmt.Fprintf(stdout, "%d bytes guardados en %s\n", n, out)
+		}
+		return nil
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("import necesita el archivo de la instantánea (- para la entrada estándar)")
+	}
+	if _, err := models.ParseImportPolicy(policy); err != nil {
+		return err
+	}
+	in := stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	stats, err := sc.importSnapshot(in, policy)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "%d películas importadas, %d omitidas\n", stats.Imported, stats.Skipped)
+	return nil
+}
+
+// envOr devuelve la variable MOVIES_<name> o el valor por defecto
+func envOr(lookupEnv func(string) (string, bool), name, def string) string {
+	if v, ok := lookupEnv(envPrefix + name); ok {
+		return v
+	}
+	return def
+}
+
+// export descarga la instantánea en path, o en stdout si path es "-"
+func (sc *snapshotClient) export(path string, stdout io.Writer) (int64, error) {
+	resp, err := sc.do(http.MethodGet, "/admin/export", nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if path == "-" {
+		return io.Copy(stdout, resp.Body)
+	}
+	// Se escribe en un temporal para no dejar una instantánea a medias
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return 0, err
+	}
+	n, err := io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return n, os.Rename(tmp, path)
+}
+
+// importSnapshot envía la instantánea al servidor
+func (sc *snapshotClient) importSnapshot(r io.Reader, policy string) (models.ImportStats, error) {
+	var stats models.ImportStats
+	resp, err := sc.do(http.MethodPost, "/admin/import?policy="+url.QueryEscape(policy), r)
+	if err != nil {
+		return stats, err
+	}
+	defer resp.Body.Close()
+
+	err = json.NewDecoder(resp.Body).Decode(&stats)
+	return stats, err
+}
+
+// do hace la petición autenticada y convierte en error las respuestas no 2xx
+func (sc *snapshotClient) do(method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequest(method, strings.TrimSuffix(sc.baseURL, "/")+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(sc.user, sc.password)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/x-ndjson")
+	}
+
+	resp, err := sc.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&apiErr) == nil && apiErr.Error != "" {
+			return nil, fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, apiErr.Error)
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, path, resp.Status)
+	}
+	return resp, nil
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prosales/go-api-movies/pkg/models"
+)
+
+// Test para los subcomandos export e import contra un servidor de pruebas
+func TestRunSnapshotCommand(t *testing.T) {
+	var imported, policy string
+	srv := httptest.NewServer(newAdminApp(t, &MockMovieModel{
+		ExportFunc: func(w io.Writer) (int, error) {
+			io.WriteString(w, "snapshot\n")
+			return 1, nil
+		},
+		ImportFunc: func(r io.Reader, p models.ImportPolicy) (models.ImportStats, error) {
+			body, _ := io.ReadAll(r)
+			imported, policy = string(body), string(p)
+			return models.ImportStats{Imported: 1}, nil
+		},
+	}))
+	defer srv.Close()
+
+	env := map[string]string{"MOVIES_URL": srv.URL, "MOVIES_ADMIN_PASSWORD": "secret"}
+	lookupEnv := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+	run := func(name string, args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		err := runSnapshotCommand(name, args, lookupEnv, strings.NewReader(""), &stdout, &stderr)
+		return stdout.String(), err
+	}
+
+	path := filepath.Join(t.TempDir(), "cache.jsonl")
+	if _, err := run("export", "--out", path); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "snapshot\n" {
+		t.Errorf("Expected the exported snapshot in the file, got %q", data)
+	}
+
+	out, err := run("import", "--policy", "overwrite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported != "snapshot\n" || policy != "overwrite" {
+		t.Errorf("Expected the file to be imported with policy overwrite, got %q %q", imported, policy)
+	}
+	if !strings.Contains(out, "1 películas importadas") {
+		t.Errorf("Expected the import summary, got %q", out)
+	}
+
+	// Credenciales incorrectas y argumentos que faltan
+	if _, err := run("export", "--admin-password", "wrong"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Expected an unauthorized error, got %v", err)
+	}
+	if _, err := run("import"); err == nil {
+		t.Error("Expected an error without a snapshot file")
+	}
+	if _, err := run("import", "--policy", "merge", path); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
+
+// Test para el subcomando migrate sobre un directorio de caché
+func TestRunMigrateCommand(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lookupEnv := func(k string) (string, bool) {
+		return dir, k == "MOVIES_CACHE_DIR"
+	}
+
+	var stdout bytes.Buffer
+	if err := runMigrateCommand(context.Background(), nil, lookupEnv, &stdout, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(stdout.String(), "1 en cuarentena") {
+		t.Errorf("Expected the migration summary, got %q", stdout.String())
+	}
+	if err := runMigrateCommand(context.Background(), nil, func(string) (string, bool) { return "", false }, io.Discard, io.Discard); err == nil {
+		t.Error("Expected an error without a cache directory")
+	}
+}
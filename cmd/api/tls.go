@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// certReloader sirve el certificado TLS y permite sustituirlo sin reiniciar
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+// newCertReloader carga el certificado y la clave indicados
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload vuelve a leer el certificado. Si no se puede cargar se sigue usando el anterior.
+func (r *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.cert = &cert
+	r.mu.Unlock()
+	return nil
+}
+
+// getCertificate implementa tls.Config.GetCertificate
+func (r *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// reloadOnSIGHUP recarga el certificado cada vez que el proceso recibe SIGHUP,
+// hasta que ctx se cancela
+func (r *certReloader) reloadOnSIGHUP(ctx context.Context) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	defer signal.Stop(sighup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sighup:
+			if err := r.reload(); err != nil {
+				slog.Error("certificate reload failed, keeping the previous one", "error", err)
+				continue
+			}
+			slog.Info("certificate reloaded", "cert", r.certFile)
+		}
+	}
+}
+
+// configureTLS prepara srv para servir HTTPS con el certificado de r. HTTP/2 se
+// negocia por ALPN salvo que se desactive con http2.
+func configureTLS(srv *http.Server, r *certReloader, http2 bool) {
+	srv.TLSConfig = &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: r.getCertificate,
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetHTTP1(true)
+	protocols.SetHTTP2(http2)
+	srv.Protocols = protocols
+}
+
+// redirectHTTPS redirige de forma permanente las peticiones HTTP a la misma URL
+// en HTTPS, en el puerto de tlsAddr
+func redirectHTTPS(tlsAddr string) http.Handler {
+	_, port, _ := net.SplitHostPort(tlsAddr)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		target := "https://" + host + r.URL.RequestURI()
+		// 308 conserva el método y el cuerpo, a diferencia de 301
+		http.Redirect(w, r, target, http.StatusPermanentRedirect)
+	})
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeSelfSignedCert genera un certificado autofirmado para localhost y lo
+// escribe en dir. Devuelve las rutas del certificado y la clave y el certificado.
+func writeSelfSignedCert(t *testing.T, dir, commonName string) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err = x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile, cert
+}
+
+// startTLSServer sirve handler por HTTPS con el certificado de certs
+func startTLSServer(t *testing.T, certs *certReloader, http2 bool, handler http.Handler) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: handler}
+	configureTLS(srv, certs, http2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- runServer(ctx, srv, ln, time.Second)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return "https://" + ln.Addr().String()
+}
+
+// tlsClient crea un cliente que confía en los certificados indicados
+func tlsClient(certs ...*x509.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	for _, c := range certs {
+		pool.AddCert(c)
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{RootCAs: pool},
+			ForceAttemptHTTP2: true,
+		},
+	}
+}
+
+// Test para configureTLS: se sirve HTTPS y se negocia HTTP/2 salvo que se desactive
+func TestServeTLS_HTTP2(t *testing.T) {
+	certFile, keyFile, cert := writeSelfSignedCert(t, t.TempDir(), "test")
+	certs, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil {
+			t.Error("Expected a TLS request")
+		}
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		http2 bool
+		proto int
+	}{
+		{true, 2},
+		{false, 1},
+	}
+	for _, tt := range tests {
+		url := startTLSServer(t, certs, tt.http2, handler)
+		resp, err := tlsClient(cert).Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.ProtoMajor != tt.proto {
+			t.Errorf("http2=%v: expected HTTP/%d, got %s", tt.http2, tt.proto, resp.Proto)
+		}
+	}
+}
+
+// Test para certReloader: el certificado nuevo se usa sin reiniciar el servidor
+// y uno no válido no sustituye al anterior
+func TestCertReloader_Reload(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile, first := writeSelfSignedCert(t, dir, "first")
+	certs, err := newCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	url := startTLSServer(t, certs, true, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	servedCN := func(client *http.Client) string {
+		t.Helper()
+		resp, err := client.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.TLS.PeerCertificates[0].Subject.CommonName
+	}
+	if cn := servedCN(tlsClient(first)); cn != "first" {
+		t.Fatalf("Expected first certificate, got %s", cn)
+	}
+
+	_, _, second := writeSelfSignedCert(t, dir, "second")
+	if err := certs.reload(); err != nil {
+		t.Fatal(err)
+	}
+	// Un cliente nuevo abre una conexión nueva y recibe el certificado recargado
+	if cn := servedCN(tlsClient(second)); cn != "second" {
+		t.Errorf("Expected reloaded certificate, got %s", cn)
+	}
+
+	// Un archivo corrupto no sustituye al certificado cargado
+	if err := os.WriteFile(certFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := certs.reload(); err == nil {
+		t.Fatal("Expected reload of an invalid certificate to fail")
+	}
+	if c, _ := certs.getCertificate(nil); c == nil || c.Leaf == nil || c.Leaf.Subject.CommonName != "second" {
+		t.Error("Expected previous certificate to be kept")
+	}
+}
+
+// Test para redirectHTTPS
+func TestRedirectHTTPS(t *testing.T) {
+	tests := []struct {
+		tlsAddr  string
+		host     string
+		target   string
+		expected string
+	}{
+		{":443", "example.com", "/search?query=star", "https://example.com/search?query=star"},
+		{":443", "example.com:80", "/", "https://example.com/"},
+		{":8443", "example.com:8080", "/movie/tt0076759", "https://example.com:8443/movie/tt0076759"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("POST", tt.target, nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		redirectHTTPS(tt.tlsAddr).ServeHTTP(w, req)
+
+		if w.Code != http.StatusPermanentRedirect {
+			t.Errorf("Expected status code %d, got %d", http.StatusPermanentRedirect, w.Code)
+		}
+		if got := w.Header().Get("Location"); got != tt.expected {
+			t.Errorf("Expected redirect to %s, got %s", tt.expected, got)
+		}
+	}
+}
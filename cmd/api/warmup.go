@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/models"
+)
+
+// cacheWarmer lo implementan los modelos que pueden precargar su caché
+type cacheWarmer interface {
+	WarmWithOptions(ctx context.Context, queries []string, opts models.WarmOptions) []models.WarmResult
+}
+
+// warmupStatus es el progreso de una precarga, tal como se muestra en /readyz y
+// en /admin/warm
+type warmupStatus struct {
+	State  string `json:"state"`
+	Total  int    `json:"total"`
+	Done   int    `json:"done"`
+	Failed int    `json:"failed"`
+}
+
+// warmup sigue el progreso de una precarga: la de arranque o la lanzada desde /admin
+type warmup struct {
+	mu      sync.Mutex
+	status  warmupStatus
+	results []models.WarmResult
+}
+
+// newWarmup crea el seguimiento de una precarga de total películas
+func newWarmup(total int) *warmup {
+	return &warmup{status: warmupStatus{State: "running", Total: total}}
+}
+
+// snapshot devuelve una copia del progreso actual
+func (wu *warmup) snapshot() warmupStatus {
+	wu.mu.Lock()
+	defer wu.mu.Unlock()
+	return wu.status
+}
+
+// finished devuelve los resultados si la precarga ya terminó
+func (wu *warmup) finished() ([]models.WarmResult, bool) {
+	wu.mu.Lock()
+	defer wu.mu.Unlock()
+	return wu.results, wu.status.State != "running"
+}
+
+// readWarmList lee un archivo con un título o ID de IMDb por línea. Se ignoran
+// las líneas vacías y las que empiezan por #.
+func readWarmList(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var queries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		queries = append(queries, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return queries, nil
+}
+
+// runWarmup precarga la caché e informa del progreso en los logs y en wu
+func runWarmup(ctx context.Context, wu *warmup, warmer cacheWarmer, queries []string, opts models.WarmOptions) {
+	start := time.Now()
+	slog.Info("cache warm-up started", "total", len(queries), "concurrency", opts.Concurrency, "rate", opts.Rate)
+
+	// Informar en los logs cada 10 % completado
+	lastDecile := 0
+	cached := 0
+	opts.OnResult = func(result models.WarmResult, progress models.WarmProgress) {
+		wu.mu.Lock()
+		wu.status.Done = progress.Done
+		wu.status.Failed = progress.Failed
+		wu.mu.Unlock()
+
+		if result.Err != nil {
+			slog.Warn("cache warm-up failed", "query", result.Query, "error", result.Err)
+		}
+		if result.Cached {
+			cached++
+		}
+		if decile := progress.Done * 10 / progress.Total; decile > lastDecile && progress.Done < progress.Total {
+			lastDecile = decile
+			slog.Info("cache warm-up progress", "done", progress.Done, "total", progress.Total, "failed", progress.Failed)
+		}
+	}
+
+	results := warmer.WarmWithOptions(ctx, queries, opts)
+
+	// Si se canceló (al apagar el servidor), las películas que faltan no se
+	// han precargado
+	state := "done"
+	if ctx.Err() != nil {
+		state = "cancelled"
+	}
+	wu.mu.Lock()
+	wu.status.State = state
+	wu.results = results
+	status := wu.status
+	wu.mu.Unlock()
+
+	if state == "cancelled" {
+		slog.Warn("cache warm-up cancelled",
+			"done", status.Done,
+			"total", status.Total,
+			"failed", status.Failed,
+			"duration", time.Since(start).Round(time.Millisecond),
+		)
+		return
+	}
+	slog.Info("cache warm-up finished",
+		"total", status.Total,
+		"failed", status.Failed,
+		"already_cached", cached,
+		"duration", time.Since(start).Round(time.Millisecond),
+	)
+}
+
+// checkWarmup falla mientras la precarga de arranque no haya terminado o si se
+// canceló antes de terminar
+func (app *application) checkWarmup(ctx context.Context) error {
+	status := app.warmup.snapshot()
+	if status.State == "cancelled" {
+		return fmt.Errorf("precarga cancelada: %d/%d", status.Done, status.Total)
+	}
+	if status.State != "done" {
+		return fmt.Errorf("precarga en curso: %d/%d", status.Done, status.Total)
+	}
+	return nil
+}
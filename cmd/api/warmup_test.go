@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prosales/go-api-movies/pkg/models"
+)
+
+// mockWarmer simula la precarga y deja que el test decida cuándo termina
+type mockWarmer struct {
+	release chan struct{}
+	queries []string
+}
+
+func (m *mockWarmer) WarmWithOptions(ctx context.Context, queries []string, opts models.WarmOptions) []models.WarmResult {
+	m.queries = queries
+	var results []models.WarmResult
+	progress := models.WarmProgress{Total: len(queries)}
+	for _, q := range queries {
+		select {
+		case <-m.release:
+		case <-ctx.Done():
+			return results
+		}
+		result := models.WarmResult{Query: q}
+		if q == "missing" {
+			result.Err = errors.New("not found")
+			progress.Failed++
+		}
+		progress.Done++
+		opts.OnResult(result, progress)
+		results = append(results, result)
+	}
+	return results
+}
+
+// Test para readWarmList: se ignoran las líneas vacías y los comentarios
+func TestReadWarmList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "warm.txt")
+	content := "# Clásicos\nStar Wars\n\n  tt0078748  \n# fin\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	queries, err := readWarmList(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(queries) != 2 || queries[0] != "Star Wars" || queries[1] != "tt0078748" {
+		t.Errorf("Expected [Star Wars tt0078748], got %q", queries)
+	}
+
+	if _, err := readWarmList(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+// Test para runWarmup: el progreso se muestra en /readyz y, con
+// warmBlocksReady, la aplicación no está disponible hasta terminar
+func TestRunWarmup_Readiness(t *testing.T) {
+	app := newReadyApp(t)
+	queries := []string{"Star Wars", "missing"}
+	app.warmup = newWarmup(len(queries))
+	app.warmBlocksReady = true
+
+	warmer := &mockWarmer{release: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		runWarmup(context.Background(), app.warmup, warmer, queries, models.WarmOptions{Concurrency: 1})
+		close(done)
+	}()
+
+	readyz := func() healthResponse {
+		t.Helper()
+		w := httptest.NewRecorder()
+		app.readyzHandler(w, httptest.NewRequest("GET", "/readyz", nil))
+		return decodeHealth(t, w)
+	}
+
+	warmer.release <- struct{}{}
+	resp := readyz()
+	if resp.Status != "fail" || resp.Checks["warmup"].Status != "fail" {
+		t.Errorf("Expected readiness to fail during the warm-up, got %+v", resp)
+	}
+	if resp.Warmup == nil || resp.Warmup.State != "running" || resp.Warmup.Total != 2 {
+		t.Errorf("Expected running warm-up progress, got %+v", resp.Warmup)
+	}
+
+	warmer.release <- struct{}{}
+	<-done
+	resp = readyz()
+	if resp.Status != "ok" {
+		t.Errorf("Expected readiness once the warm-up finished, got %+v", resp)
+	}
+	if resp.Warmup.State != "done" || resp.Warmup.Done != 2 || resp.Warmup.Failed != 1 {
+		t.Errorf("Expected finished warm-up with one failure, got %+v", resp.Warmup)
+	}
+}
+
+// Test para runWarmup: una precarga cancelada no queda como terminada
+func TestRunWarmup_Cancelled(t *testing.T) {
+	app := newReadyApp(t)
+	queries := []string{"Star Wars", "Alien"}
+	app.warmup = newWarmup(len(queries))
+	app.warmBlocksReady = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	warmer := &mockWarmer{release: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		runWarmup(ctx, app.warmup, warmer, queries, models.WarmOptions{Concurrency: 1})
+		close(done)
+	}()
+
+	warmer.release <- struct{}{}
+	cancel()
+	<-done
+
+	if status := app.warmup.snapshot(); status.State != "cancelled" || status.Done != 1 || status.Total != 2 {
+		t.Errorf("Expected a cancelled warm-up after one movie, got %+v", status)
+	}
+	if err := app.checkWarmup(context.Background()); err == nil {
+		t.Error("Expected readiness to fail after a cancelled warm-up")
+	}
+}
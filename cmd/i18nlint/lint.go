@@ -0,0 +1,488 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/prosales/go-api-movies/pkg/i18n"
+)
+
+// Tipos de problema que se informan
+const (
+	problemMissing      = "missing"
+	problemUnused       = "unused"
+	problemPlaceholders = "placeholders"
+)
+
+// problem es un problema encontrado en las traducciones
+type problem struct {
+	kind string
+	pos  string // Archivo y línea, o el archivo de traducciones
+	msg  string
+}
+
+func (p problem) String() string {
+	return fmt.Sprintf("%s: %s: %s", p.pos, p.kind, p.msg)
+}
+
+// usage es un uso de una clave con su posición y, si se conocen, los
+// marcadores que recibe. args es nil si no se pueden saber.
+type usage struct {
+	key    string
+	pos    string
+	fn     string // t, tf o tn; T, Tf, Tn, una de keyWrappers o una de keyTables en Go
+	args   []string
+	plural bool
+}
+
+// options son las rutas que revisa lint
+type options struct {
+	templates   string
+	locales     string
+	src         string
+	defaultLang string
+}
+
+// lint revisa las traducciones de opts.locales contra los usos de las
+// plantillas y del código Go, y devuelve los problemas ordenados
+func lint(opts options) ([]problem, error) {
+	translator, err := i18n.NewTranslator(os.DirFS(opts.locales), opts.defaultLang)
+	if err != nil {
+		return nil, err
+	}
+	langs := translator.AvailableLanguages()
+	if !slices.Contains(langs, opts.defaultLang) {
+		return nil, fmt.Errorf("no hay traducciones del idioma predeterminado %s en %s", opts.defaultLang, opts.locales)
+	}
+
+	usages, err := templateUsages(opts.templates)
+	if err != nil {
+		return nil, err
+	}
+	goUsages, err := goUsages(opts.src)
+	if err != nil {
+		return nil, err
+	}
+	usages = append(usages, goUsages...)
+
+	localeFile := func(lang string) string {
+		return filepath.Join(opts.locales, lang, "messages.json")
+	}
+	var problems []problem
+
+	// Claves usadas que faltan en algún idioma
+	used := make(map[string]bool)
+	for _, u := range usages {
+		used[u.key] = true
+		for _, lang := range langs {
+			if _, ok := translator.MessagePlaceholders(lang, u.key); !ok {
+				problems = append(problems, problem{problemMissing, u.pos, fmt.Sprintf("%q no está en %s", u.key, localeFile(lang))})
+			}
+		}
+	}
+
+	// Claves de algún idioma que faltan en otro o que no se usan
+	all := make(map[string]bool)
+	for _, lang := range langs {
+		for _, key := range translator.Keys(lang) {
+			all[key] = true
+		}
+	}
+	for _, key := range sortedKeys(all) {
+		if used[key] {
+			continue // Las que faltan ya se informaron al revisar sus usos
+		}
+		for _, lang := range langs {
+			if _, ok := translator.MessagePlaceholders(lang, key); ok {
+				problems = append(problems, problem{problemUnused, localeFile(lang), fmt.Sprintf("%q no se usa", key)})
+			} else {
+				problems = append(problems, problem{problemMissing, localeFile(lang), fmt.Sprintf("%q está en otros idiomas", key)})
+			}
+		}
+	}
+
+	// Marcadores distintos entre idiomas
+	for _, key := range sortedKeys(all) {
+		want, ok := translator.MessagePlaceholders(opts.defaultLang, key)
+		if !ok {
+			continue
+		}
+		for _, lang := range langs {
+			got, ok := translator.MessagePlaceholders(lang, key)
+			if ok && lang != opts.defaultLang && !sameSet(got, want) {
+				problems = append(problems, problem{problemPlaceholders, localeFile(lang),
+					fmt.Sprintf("%q usa %s y en %s %s", key, formatNames(got), opts.defaultLang, formatNames(want))})
+			}
+		}
+	}
+
+	// Marcadores que no coinciden con los argumentos de cada uso
+	for _, u := range usages {
+		names, ok := translator.MessagePlaceholders(opts.defaultLang, u.key)
+		if !ok || u.args == nil {
+			continue
+		}
+		given := u.args
+		if u.plural {
+			given = append([]string{"count"}, given...)
+		}
+		if missing := difference(names, given); len(missing) > 0 {
+			problems = append(problems, problem{problemPlaceholders, u.pos,
+				fmt.Sprintf("%s %q no da valor a %s", u.fn, u.key, formatNames(missing))})
+		}
+		if extra := difference(u.args, names); len(extra) > 0 {
+			problems = append(problems, problem{problemPlaceholders, u.pos,
+				fmt.Sprintf("%s %q da valor a %s, que el mensaje no usa", u.fn, u.key, formatNames(extra))})
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].pos != problems[j].pos {
+			return problems[i].pos < problems[j].pos
+		}
+		return problems[i].msg < problems[j].msg
+	})
+	return problems, nil
+}
+
+// templateUsages busca las llamadas a t, tf y tn con una clave literal en las
+// plantillas *.html de dir
+func templateUsages(dir string) ([]usage, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+
+	var usages []usage
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		// Las funciones no se comprueban: se definen al cargar las plantillas
+		tree := parse.New(file)
+		tree.Mode = parse.SkipFuncCheck
+		trees := make(map[string]*parse.Tree)
+		if _, err := tree.Parse(string(data), "", "", trees); err != nil {
+			return nil, err
+		}
+		for _, t := range trees {
+			walkTemplate(t, t.Root, &usages)
+		}
+	}
+	return usages, nil
+}
+
+// walkTemplate recorre node y añade a usages los usos de claves
+func walkTemplate(tree *parse.Tree, node parse.Node, usages *[]usage) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			walkTemplate(tree, child, usages)
+		}
+	case *parse.ActionNode:
+		walkTemplate(tree, n.Pipe, usages)
+	case *parse.IfNode:
+		walkBranch(tree, &n.BranchNode, usages)
+	case *parse.RangeNode:
+		walkBranch(tree, &n.BranchNode, usages)
+	case *parse.WithNode:
+		walkBranch(tree, &n.BranchNode, usages)
+	case *parse.TemplateNode:
+		walkTemplate(tree, n.Pipe, usages)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, cmd := range n.Cmds {
+			walkTemplate(tree, cmd, usages)
+		}
+	case *parse.CommandNode:
+		if u, ok := templateCall(tree, n); ok {
+			*usages = append(*usages, u)
+		}
+		for _, arg := range n.Args {
+			walkTemplate(tree, arg, usages)
+		}
+	}
+}
+
+// walkBranch recorre los nodos de if, range y with
+func walkBranch(tree *parse.Tree, n *parse.BranchNode, usages *[]usage) {
+	walkTemplate(tree, n.Pipe, usages)
+	walkTemplate(tree, n.List, usages)
+	walkTemplate(tree, n.ElseList, usages)
+}
+
+// templateCall reconoce {{t "clave"}}, {{tf "clave" "nombre" valor ...}} y
+// {{tn "clave" n "nombre" valor ...}}
+func templateCall(tree *parse.Tree, cmd *parse.CommandNode) (usage, bool) {
+	if len(cmd.Args) < 2 {
+		return usage{}, false
+	}
+	fn, ok := cmd.Args[0].(*parse.IdentifierNode)
+	if !ok || fn.Ident != "t" && fn.Ident != "tf" && fn.Ident != "tn" {
+		return usage{}, false
+	}
+	key, ok := cmd.Args[1].(*parse.StringNode)
+	if !ok {
+		return usage{}, false
+	}
+	location, _ := tree.ErrorContext(cmd)
+	u := usage{key: key.Text, pos: trimColumn(location), fn: fn.Ident, args: []string{}}
+
+	rest := cmd.Args[2:]
+	if fn.Ident == "tn" {
+		u.plural = true
+		if len(rest) > 0 {
+			rest = rest[1:]
+		}
+	}
+	for i := 0; i < len(rest); i += 2 {
+		name, ok := rest[i].(*parse.StringNode)
+		if !ok {
+			u.args = nil
+			break
+		}
+		u.args = append(u.args, name.Text)
+	}
+	return u, true
+}
+
+// Funciones que reciben una clave y la traducen ellas, y tablas de claves que
+// se traducen al usarlas. Sus claves no se pueden comprobar contra los
+// marcadores, pero cuentan como usadas.
+var (
+	keyWrappers = map[string]bool{"renderError": true, "upstreamError": true}
+	keyTables   = map[string]bool{"adminNotices": true, "upstreamErrorKeys": true}
+)
+
+// goUsages busca en el código Go de dir, sin los tests, las claves literales
+// de las llamadas a T, Tf y Tn de un traductor, de las llamadas a keyWrappers
+// y de los valores de keyTables
+func goUsages(dir string) ([]usage, error) {
+	var usages []usage
+	fset := token.NewFileSet()
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != dir && (strings.HasPrefix(name, ".") || name == "vendor" || name == "testdata") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return err
+		}
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.CallExpr:
+				if u, ok := goCall(fset, n); ok {
+					usages = append(usages, u)
+				}
+				if name := funcName(n.Fun); keyWrappers[name] {
+					usages = append(usages, literalUsages(fset, name, n.Args)...)
+				}
+			case *ast.ValueSpec:
+				for i, ident := range n.Names {
+					if keyTables[ident.Name] && i < len(n.Values) {
+						if table, ok := n.Values[i].(*ast.CompositeLit); ok {
+							usages = append(usages, tableUsages(fset, ident.Name, table)...)
+						}
+					}
+				}
+			}
+			return true
+		})
+		return nil
+	})
+	return usages, err
+}
+
+// funcName devuelve el nombre de la función o método llamado
+func funcName(fun ast.Expr) string {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name
+	case *ast.SelectorExpr:
+		return f.Sel.Name
+	}
+	return ""
+}
+
+// literalUsages devuelve un uso por cada texto literal de exprs
+func literalUsages(fset *token.FileSet, fn string, exprs []ast.Expr) []usage {
+	var usages []usage
+	for _, expr := range exprs {
+		lit, ok := expr.(*ast.BasicLit)
+		if !ok {
+			continue
+		}
+		if s, ok := stringLit(lit); ok {
+			usages = append(usages, usage{key: s, pos: position(fset, lit.Pos()), fn: fn})
+		}
+	}
+	return usages
+}
+
+// tableUsages devuelve un uso por cada valor literal de la tabla
+func tableUsages(fset *token.FileSet, name string, table *ast.CompositeLit) []usage {
+	values := make([]ast.Expr, 0, len(table.Elts))
+	for _, elt := range table.Elts {
+		if kv, ok := elt.(*ast.KeyValueExpr); ok {
+			values = append(values, kv.Value)
+		}
+	}
+	return literalUsages(fset, name, values)
+}
+
+// position escribe pos como archivo:línea
+func position(fset *token.FileSet, pos token.Pos) string {
+	p := fset.Position(pos)
+	return p.Filename + ":" + strconv.Itoa(p.Line)
+}
+
+// goCall reconoce translator.T(lang, "clave"), translator.Tf(lang, "clave",
+// i18n.Args{...}) y translator.Tn(lang, "clave", n, i18n.Args{...})
+func goCall(fset *token.FileSet, call *ast.CallExpr) (usage, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok || !isTranslator(sel.X) {
+		return usage{}, false
+	}
+	argsIndex := map[string]int{"T": -1, "Tf": 2, "Tn": 3}
+	i, ok := argsIndex[sel.Sel.Name]
+	if !ok || len(call.Args) < 2 {
+		return usage{}, false
+	}
+	key, ok := call.Args[1].(*ast.BasicLit)
+	if !ok {
+		return usage{}, false
+	}
+	s, ok := stringLit(key)
+	if !ok {
+		return usage{}, false
+	}
+
+	u := usage{
+		key:    s,
+		pos:    position(fset, call.Pos()),
+		fn:     sel.Sel.Name,
+		args:   []string{},
+		plural: sel.Sel.Name == "Tn",
+	}
+	if i < 0 || i >= len(call.Args) {
+		return u, true
+	}
+	switch args := call.Args[i].(type) {
+	case *ast.Ident:
+		if args.Name != "nil" {
+			u.args = nil
+		}
+	case *ast.CompositeLit:
+		for _, elt := range args.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				u.args = nil
+				break
+			}
+			name, ok := kv.Key.(*ast.BasicLit)
+			if !ok {
+				u.args = nil
+				break
+			}
+			s, _ := stringLit(name)
+			u.args = append(u.args, s)
+		}
+	default:
+		u.args = nil
+	}
+	return u, true
+}
+
+// isTranslator indica si x es translator o algo.translator
+func isTranslator(x ast.Expr) bool {
+	switch x := x.(type) {
+	case *ast.Ident:
+		return x.Name == "translator"
+	case *ast.SelectorExpr:
+		return x.Sel.Name == "translator"
+	}
+	return false
+}
+
+// stringLit devuelve el valor de un literal de texto
+func stringLit(lit *ast.BasicLit) (string, bool) {
+	if lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// trimColumn quita la columna de una posición archivo:línea:columna
+func trimColumn(location string) string {
+	if i := strings.LastIndex(location, ":"); i > strings.Index(location, ":") {
+		return location[:i]
+	}
+	return location
+}
+
+// difference devuelve los elementos de a que no están en b
+func difference(a, b []string) []string {
+	var diff []string
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			diff = append(diff, s)
+		}
+	}
+	return diff
+}
+
+// sameSet indica si a y b tienen los mismos elementos
+func sameSet(a, b []string) bool {
+	return len(difference(a, b)) == 0 && len(difference(b, a)) == 0
+}
+
+// formatNames escribe una lista de marcadores como {a}, {b}
+func formatNames(names []string) string {
+	if len(names) == 0 {
+		return "ningún marcador"
+	}
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = "{" + name + "}"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// sortedKeys devuelve las claves de m ordenadas
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
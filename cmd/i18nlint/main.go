@@ -0,0 +1,51 @@
+// Command i18nlint revisa las traducciones: informa de las claves que usan las
+// plantillas o el código y faltan en algún idioma, de las que no se usan y de
+// los marcadores que no coinciden entre idiomas o con los argumentos de cada uso.
+//
+//	go run ./cmd/i18nlint [-templates templates] [-locales locales] [-src .] [-lang es]
+//
+// Sale con 1 si encuentra problemas y con 2 si no puede revisarlas.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run ejecuta la revisión con los argumentos args y devuelve el código de salida
+func run(args []string, stdout, stderr io.Writer) int {
+	var opts options
+	fs := flag.NewFlagSet("i18nlint", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&opts.templates, "templates", "templates", "directorio de las plantillas")
+	fs.StringVar(&opts.locales, "locales", "locales", "directorio de las traducciones")
+	fs.StringVar(&opts.src, "src", ".", "directorio del código Go")
+	fs.StringVar(&opts.defaultLang, "lang", "es", "idioma de referencia")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(stderr, "i18nlint: argumentos no esperados: %v\n", fs.Args())
+		return 2
+	}
+
+	problems, err := lint(opts)
+	if err != nil {
+		fmt.Fprintf(stderr, "i18nlint: %v\n", err)
+		return 2
+	}
+	for _, p := range problems {
+		fmt.Fprintln(stdout, p)
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(stderr, "i18nlint: %d problemas\n", len(problems))
+		return 1
+	}
+	return 0
+}
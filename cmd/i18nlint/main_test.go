@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun_Repo(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"-templates", "../../templates", "-locales", "../../locales", "-src", "../.."}, &stdout, &stderr)
+	if code != 0 {
+		t.Errorf("Expected exit code 0, got %d:\n%s%s", code, stdout.String(), stderr.String())
+	}
+}
+
+func TestRun_Problems(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"templates/page.html": `{{define "page"}}
+<h1>{{t "title"}}</h1>
+{{if .Results}}{{tf "results" "query" .Query}}{{end}}
+{{range .Items}}{{tn "items" .N "extra" 1}}{{end}}
+{{t "missing_everywhere"}}
+{{t "greeting"}}
+{{end}}`,
+		"src/app.go": `package app
+
+var adminNotices = map[string]string{"done": "from_table"}
+
+func f(translator interface{ T(string, string) string }) {
+	translator.T("es", "from_go")
+	translator.Tf("es", "title", []string{"not", "key", "value"})
+	app.renderError(w, r, 500, "by_wrapper")
+	_ = "stray"
+}
+`,
+		"locales/es/messages.json": `{
+  "title": "Título",
+  "results": "Resultados de {query}",
+  "items": {"one": "{count} elemento", "other": "{count} elementos"},
+  "greeting": "Hola {name}",
+  "from_go": "Desde Go",
+  "by_wrapper": "Por función",
+  "from_table": "Desde tabla",
+  "stray": "Suelto",
+  "only_es": "Solo en español",
+  "unused": "Sin usar"
+}`,
+		"locales/en/messages.json": `{
+  "title": "Title",
+  "results": "Results for {search}",
+  "items": {"one": "{count} item", "other": "{count} items"},
+  "greeting": "Hello {name}",
+  "by_wrapper": "By wrapper",
+  "from_table": "From table",
+  "stray": "Stray",
+  "unused": "Unused"
+}`,
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	code := run([]string{
+		"-templates", filepath.Join(dir, "templates"),
+		"-locales", filepath.Join(dir, "locales"),
+		"-src", filepath.Join(dir, "src"),
+	}, &stdout, &stderr)
+	if code != 1 {
+		t.Fatalf("Expected exit code 1, got %d: %s", code, stderr.String())
+	}
+
+	out := stdout.String()
+	for _, want := range []string{
+		`page.html:5: missing: "missing_everywhere" no está en`,
+		`app.go:6: missing: "from_go" no está en ` + filepath.Join(dir, "locales", "en", "messages.json"),
+		`es/messages.json: unused: "stray" no se usa`,
+		`en/messages.json: missing: "only_es" está en otros idiomas`,
+		`es/messages.json: unused: "only_es" no se usa`,
+		`es/messages.json: unused: "unused" no se usa`,
+		`en/messages.json: unused: "unused" no se usa`,
+		`en/messages.json: placeholders: "results" usa {search} y en es {query}`,
+		`page.html:4: placeholders: tn "items" da valor a {extra}, que el mensaje no usa`,
+		`page.html:6: placeholders: t "greeting" no da valor a {name}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected output to contain %q, got:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{`"title"`, `"by_wrapper"`, `"from_table"`, `tf "results"`, `tn "items" no da valor`} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("Expected output not to contain %s, got:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown flag", []string{"-nope"}},
+		{"extra argument", []string{"extra"}},
+		{"missing locales", []string{"-locales", filepath.Join(t.TempDir(), "none")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := run(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("Expected exit code 2, got %d", code)
+			}
+		})
+	}
+}
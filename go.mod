@@ -1,3 +1,5 @@
 module github.com/prosales/go-api-movies
 
 go 1.24.2
+
+require golang.org/x/text v0.30.0
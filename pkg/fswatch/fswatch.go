@@ -0,0 +1,56 @@
+package fswatch
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io/fs"
+	"time"
+)
+
+// Fingerprint resume el estado de todos los archivos de fsys (ruta, tamaño y
+// fecha de modificación). Dos huellas distintas indican que algo cambió.
+func Fingerprint(fsys fs.FS) (uint64, error) {
+	h := fnv.New64a()
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(h, "%s|%d|%d|%t\n", path, info.Size(), info.ModTime().UnixNano(), d.IsDir())
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return h.Sum64(), nil
+}
+
+// Poll comprueba fsys cada interval y llama a onChange cuando cambia su huella,
+// hasta que ctx se cancela. Los errores al recorrer fsys (por ejemplo, un
+// directorio borrado) cuentan como un cambio al aparecer y al desaparecer, pero
+// no en cada comprobación mientras se repiten.
+func Poll(ctx context.Context, fsys fs.FS, interval time.Duration, onChange func()) {
+	last, lastErr := Fingerprint(fsys)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		current, err := Fingerprint(fsys)
+		if current == last && (err != nil) == (lastErr != nil) {
+			continue
+		}
+		last, lastErr = current, err
+		onChange()
+	}
+}
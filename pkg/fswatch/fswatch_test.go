@@ -0,0 +1,112 @@
+package fswatch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFingerprint(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "messages.json")
+	if err := os.WriteFile(file, []byte(`{"a":"b"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fsys := os.DirFS(dir)
+	first, err := Fingerprint(fsys)
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	again, _ := Fingerprint(fsys)
+	if first != again {
+		t.Error("Expected the same fingerprint when nothing changed")
+	}
+
+	if err := os.WriteFile(file, []byte(`{"a":"changed"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed, _ := Fingerprint(fsys)
+	if changed == first {
+		t.Error("Expected a different fingerprint after modifying a file")
+	}
+}
+
+func TestPoll(t *testing.T) {
+	dir := t.TempDir()
+	fsys := os.DirFS(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 10)
+	done := make(chan struct{})
+	go func() {
+		Poll(ctx, fsys, 10*time.Millisecond, func() { changes <- struct{}{} })
+		close(done)
+	}()
+
+	// Dar tiempo a que Poll tome la huella inicial antes de crear el archivo
+	time.Sleep(30 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "home.html"), []byte("hola"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a change to be detected")
+	}
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Poll did not stop after the context was cancelled")
+	}
+}
+
+// Mientras fsys no se puede recorrer, onChange solo se llama al aparecer el
+// error y al desaparecer, no en cada comprobación
+func TestPoll_PersistentError(t *testing.T) {
+	dir := t.TempDir()
+	watched := filepath.Join(dir, "locales")
+	if err := os.Mkdir(watched, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	fsys := os.DirFS(watched)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	changes := make(chan struct{}, 100)
+	go Poll(ctx, fsys, 10*time.Millisecond, func() { changes <- struct{}{} })
+
+	time.Sleep(30 * time.Millisecond)
+	if err := os.Remove(watched); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the error to be reported as a change")
+	}
+
+	// El error se repite en cada comprobación sin volver a avisar
+	time.Sleep(100 * time.Millisecond)
+	if n := len(changes); n != 0 {
+		t.Errorf("Expected no more changes while the error persists, got %d", n)
+	}
+
+	if err := os.Mkdir(watched, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-changes:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the recovery to be reported as a change")
+	}
+}
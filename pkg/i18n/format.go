@@ -0,0 +1,196 @@
+package i18n
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// locale reúne las convenciones de formato de un idioma, tomadas de CLDR
+type locale struct {
+	decimal string
+	group   string
+	// minGrouping es el número mínimo de dígitos por encima de los miles para
+	// agrupar: en español 1234 se escribe sin separador y 12 345 con él
+	minGrouping int
+
+	// Formatos de fecha y hora de time.Format; {month} se sustituye por la
+	// abreviatura del mes, que Go solo sabe escribir en inglés
+	date     string
+	datetime string
+	months   [12]string
+
+	// Unidades de las duraciones: días, horas, minutos, segundos y milisegundos
+	units [5]string
+}
+
+var englishLocale = locale{
+	decimal: ".", group: ",", minGrouping: 1,
+	date:     "{month} 2, 2006",
+	datetime: "{month} 2, 2006, 3:04:05 PM",
+	months:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	units:    [5]string{"d", "h", "min", "s", "ms"},
+}
+
+// Convenciones por idioma. Los que no están aquí usan las de inglés.
+var locales = map[string]locale{
+	"en": englishLocale,
+	"en-gb": {
+		decimal: ".", group: ",", minGrouping: 1,
+		date:     "2 {month} 2006",
+		datetime: "2 {month} 2006, 15:04:05",
+		months:   englishLocale.months,
+		units:    englishLocale.units,
+	},
+	"es": {
+		decimal: ",", group: ".", minGrouping: 2,
+		date:     "2 {month} 2006",
+		datetime: "2 {month} 2006, 15:04:05",
+		months:   [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		units:    [5]string{"d", "h", "min", "s", "ms"},
+	},
+	"fr": {
+		decimal: ",", group: "\u202f", minGrouping: 1,
+		date:     "2 {month} 2006",
+		datetime: "2 {month} 2006, 15:04:05",
+		months:   [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		units:    [5]string{"j", "h", "min", "s", "ms"},
+	},
+	"de": {
+		decimal: ",", group: ".", minGrouping: 1,
+		date:     "02.01.2006",
+		datetime: "02.01.2006, 15:04:05",
+		months:   englishLocale.months,
+		units:    [5]string{"T", "Std.", "Min.", "Sek.", "ms"},
+	},
+	"it": {
+		decimal: ",", group: ".", minGrouping: 2,
+		date:     "2 {month} 2006",
+		datetime: "2 {month} 2006, 15:04:05",
+		months:   [12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		units:    [5]string{"g", "h", "min", "s", "ms"},
+	},
+	"pt": {
+		decimal: ",", group: ".", minGrouping: 1,
+		date:     "2 de {month} de 2006",
+		datetime: "2 de {month} de 2006, 15:04:05",
+		months:   [12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+		units:    [5]string{"d", "h", "min", "s", "ms"},
+	},
+}
+
+// localeFor busca las convenciones de lang, o las de su idioma base
+func localeFor(lang string) locale {
+	tag := strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+	for tag != "" {
+		if l, ok := locales[tag]; ok {
+			return l
+		}
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return englishLocale
+}
+
+// FormatNumber escribe un entero con el separador de miles del idioma
+func FormatNumber(lang string, n int64) string {
+	return formatInteger(localeFor(lang), n)
+}
+
+// FormatDecimal escribe un número con prec decimales y los separadores del idioma
+func FormatDecimal(lang string, f float64, prec int) string {
+	l := localeFor(lang)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	s := strconv.FormatFloat(math.Abs(f), 'f', prec, 64)
+	intPart, frac, _ := strings.Cut(s, ".")
+	n, _ := strconv.ParseInt(intPart, 10, 64)
+
+	out := formatInteger(l, n)
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		out = "-" + out
+	}
+	if frac != "" {
+		out += l.decimal + frac
+	}
+	return out
+}
+
+// formatInteger agrupa los dígitos de n de tres en tres
+func formatInteger(l locale, n int64) string {
+	digits := strconv.FormatInt(n, 10)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) < 4+l.minGrouping-1 {
+		return sign + digits
+	}
+
+	var b strings.Builder
+	b.WriteString(sign)
+	head := len(digits) % 3
+	if head == 0 {
+		head = 3
+	}
+	b.WriteString(digits[:head])
+	for i := head; i < len(digits); i += 3 {
+		b.WriteString(l.group)
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
+
+// FormatDate escribe la fecha de t, con el mes abreviado, en el idioma lang
+func FormatDate(lang string, t time.Time) string {
+	l := localeFor(lang)
+	return formatTime(l, l.date, t)
+}
+
+// FormatDateTime escribe la fecha y la hora de t en el idioma lang
+func FormatDateTime(lang string, t time.Time) string {
+	l := localeFor(lang)
+	return formatTime(l, l.datetime, t)
+}
+
+// formatTime aplica layout y sustituye {month} por el nombre del mes
+func formatTime(l locale, layout string, t time.Time) string {
+	return strings.Replace(t.Format(layout), "{month}", l.months[t.Month()-1], 1)
+}
+
+// FormatDuration escribe d de forma legible con sus dos unidades mayores, como
+// "2 h 5 min" o "40 s". Por debajo del segundo usa milisegundos.
+func FormatDuration(lang string, d time.Duration) string {
+	l := localeFor(lang)
+	sign := ""
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	if d < time.Second {
+		return sign + formatInteger(l, d.Milliseconds()) + " " + l.units[4]
+	}
+
+	sizes := [4]time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second}
+	var parts []string
+	for i, size := range sizes {
+		n := d / size
+		d -= n * size
+		if n == 0 {
+			// Solo se juntan unidades contiguas: 2 h 5 s se escribe 2 h
+			if len(parts) > 0 {
+				break
+			}
+			continue
+		}
+		parts = append(parts, formatInteger(l, int64(n))+" "+l.units[i])
+		if len(parts) == 2 {
+			break
+		}
+	}
+	return sign + strings.Join(parts, " ")
+}
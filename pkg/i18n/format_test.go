@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+// Test para FormatNumber y FormatDecimal con los separadores de cada idioma
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		got, expected string
+	}{
+		{FormatNumber("en", 1234567), "1,234,567"},
+		{FormatNumber("en", 999), "999"},
+		{FormatNumber("en", 1000), "1,000"},
+		{FormatNumber("es", 4096), "4096"},
+		{FormatNumber("es-MX", 12345), "12.345"},
+		{FormatNumber("fr", 1234), "1\u202f234"},
+		{FormatNumber("es", -1234567), "-1.234.567"},
+		{FormatNumber("xx", 1234), "1,234"},
+		{FormatDecimal("en", 1234.5, 2), "1,234.50"},
+		{FormatDecimal("es", 12345.678, 1), "12.345,7"},
+		{FormatDecimal("es", -0.25, 2), "-0,25"},
+		{FormatDecimal("en", 3, 0), "3"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, tt.got)
+		}
+	}
+}
+
+// Test para FormatDate y FormatDateTime con los nombres de mes de cada idioma
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2024, time.August, 5, 14, 3, 9, 0, time.UTC)
+	tests := []struct {
+		got, expected string
+	}{
+		{FormatDate("es", date), "5 ago 2024"},
+		{FormatDateTime("es", date), "5 ago 2024, 14:03:09"},
+		{FormatDate("en", date), "Aug 5, 2024"},
+		{FormatDateTime("en-US", date), "Aug 5, 2024, 2:03:09 PM"},
+		{FormatDate("en-GB", date), "5 Aug 2024"},
+		{FormatDate("pt-BR", date), "5 de ago. de 2024"},
+		{FormatDate("de", date), "05.08.2024"},
+		{FormatDate("fr", date), "5 août 2024"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, tt.got)
+		}
+	}
+}
+
+// Test para FormatDuration: dos unidades contiguas como máximo
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		lang     string
+		d        time.Duration
+		expected string
+	}{
+		{"es", 2*time.Hour + 5*time.Minute + 30*time.Second, "2 h 5 min"},
+		{"es", 2*time.Hour + 30*time.Second, "2 h"},
+		{"en", 40 * time.Second, "40 s"},
+		{"en", 250 * time.Millisecond, "250 ms"},
+		{"en", 0, "0 ms"},
+		{"fr", 49 * time.Hour, "2 j 1 h"},
+		{"es", 1500 * 24 * time.Hour, "1500 d"},
+		{"en", 1500 * 24 * time.Hour, "1,500 d"},
+		{"en", -90 * time.Second, "-1 min 30 s"},
+	}
+	for _, tt := range tests {
+		if got := FormatDuration(tt.lang, tt.d); got != tt.expected {
+			t.Errorf("FormatDuration(%s, %s): expected %q, got %q", tt.lang, tt.d, tt.expected, got)
+		}
+	}
+}
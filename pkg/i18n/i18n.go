@@ -1,34 +1,131 @@
 package i18n
 
 import (
+	"context"
 	"encoding/json"
-	"io/ioutil"
-	"os"
-	"path/filepath"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
 	"sync"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/fswatch"
 )
 
 // TranslatorInterface define la interfaz para un traductor
 type TranslatorInterface interface {
 	T(lang, key string) string
+	// Tf traduce una clave sustituyendo sus marcadores {nombre} por args
+	Tf(lang, key string, args Args) string
+	// Tn traduce una clave con la forma de plural que corresponde a n, que
+	// también sustituye al marcador {count}
+	Tn(lang, key string, n int, args Args) string
+	// AvailableLanguages devuelve los códigos de idioma cargados, ordenados
+	AvailableLanguages() []string
+}
+
+// Args son los valores de los marcadores de un mensaje, por nombre
+type Args map[string]any
+
+// message es una traducción: un texto, o una forma por categoría de plural
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+// UnmarshalJSON lee un mensaje de messages.json: un texto o un objeto con una
+// forma por categoría de plural de CLDR, entre las que es obligatoria other
+func (m *message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.text); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &m.plural); err != nil {
+		return errors.New("se espera un texto o un objeto con formas de plural")
+	}
+	for category := range m.plural {
+		if !pluralCategories[category] {
+			return fmt.Errorf("categoría de plural %q no válida", category)
+		}
+	}
+	other, ok := m.plural[PluralOther]
+	if !ok {
+		return errors.New("falta la forma de plural other")
+	}
+	m.text = other
+	return nil
+}
+
+// form devuelve el texto para la categoría de plural, o other si no la tiene
+func (m message) form(category string) string {
+	if text, ok := m.plural[category]; ok {
+		return text
+	}
+	return m.text
 }
 
 // Translator maneja las traducciones i18n
 type Translator struct {
-	translations map[string]map[string]string
+	translations map[string]map[string]message
 	defaultLang  string
+	fsys         fs.FS
 	mu           sync.RWMutex
 }
 
-// NewTranslator crea un nuevo traductor con el idioma predeterminado
-func NewTranslator(localesDir, defaultLang string) (*Translator, error) {
-	t := &Translator{
-		translations: make(map[string]map[string]string),
+// NewTranslator crea un nuevo traductor con el idioma predeterminado. fsys
+// contiene un directorio por idioma con su archivo messages.json.
+func NewTranslator(fsys fs.FS, defaultLang string) (*Translator, error) {
+	translations, err := loadTranslations(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Translator{
+		translations: translations,
+		defaultLang:  defaultLang,
+		fsys:         fsys,
+	}, nil
+}
+
+// NewEmptyTranslator crea un traductor sin traducciones que lee las de fsys al
+// llamar a Reload o Watch. Sirve para arrancar aunque aún no se puedan leer.
+func NewEmptyTranslator(fsys fs.FS, defaultLang string) *Translator {
+	return &Translator{
+		translations: make(map[string]map[string]message),
 		defaultLang:  defaultLang,
+		fsys:         fsys,
+	}
+}
+
+// Reload vuelve a leer las traducciones y las sustituye de una sola vez. Si
+// alguna no se puede leer se conservan las anteriores.
+func (t *Translator) Reload() error {
+	translations, err := loadTranslations(t.fsys)
+	if err != nil {
+		return err
 	}
 
+	t.mu.Lock()
+	t.translations = translations
+	t.mu.Unlock()
+	return nil
+}
+
+// Watch recarga las traducciones cada vez que cambian sus archivos, comprobándolos
+// cada interval hasta que ctx se cancela. onReload recibe el resultado de cada recarga.
+func (t *Translator) Watch(ctx context.Context, interval time.Duration, onReload func(error)) {
+	fswatch.Poll(ctx, t.fsys, interval, func() {
+		onReload(t.Reload())
+	})
+}
+
+// loadTranslations carga el archivo messages.json de cada idioma de fsys
+func loadTranslations(fsys fs.FS) (map[string]map[string]message, error) {
+	translations := make(map[string]map[string]message)
+
 	// Cargar todos los idiomas disponibles en el directorio locales
-	langDirs, err := ioutil.ReadDir(localesDir)
+	langDirs, err := fs.ReadDir(fsys, ".")
 	if err != nil {
 		return nil, err
 	}
@@ -40,46 +137,136 @@ func NewTranslator(localesDir, defaultLang string) (*Translator, error) {
 
 		lang := langDir.Name()
 		// Cargar archivo messages.json para este idioma
-		messagesFile := filepath.Join(localesDir, lang, "messages.json")
-		if _, err := os.Stat(messagesFile); os.IsNotExist(err) {
+		messagesFile := path.Join(lang, "messages.json")
+		data, err := fs.ReadFile(fsys, messagesFile)
+		if errors.Is(err, fs.ErrNotExist) {
 			continue
 		}
-
-		data, err := ioutil.ReadFile(messagesFile)
 		if err != nil {
 			return nil, err
 		}
 
-		var messages map[string]string
+		var messages map[string]message
 		if err := json.Unmarshal(data, &messages); err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%s: %w", messagesFile, err)
 		}
 
-		t.translations[lang] = messages
+		translations[lang] = messages
 	}
 
-	return t, nil
+	return translations, nil
 }
 
-// T traduce una clave al idioma especificado
+// T traduce una clave al idioma especificado. De los mensajes con plural
+// devuelve la forma other.
 func (t *Translator) T(lang, key string) string {
+	msg, _, ok := t.lookup(lang, key)
+	if !ok {
+		// Si todo falla, devuelve la clave como respaldo
+		return key
+	}
+	return msg.text
+}
+
+// Tf traduce una clave al idioma especificado sustituyendo sus marcadores
+func (t *Translator) Tf(lang, key string, args Args) string {
+	msg, _, ok := t.lookup(lang, key)
+	if !ok {
+		return key
+	}
+	return interpolate(msg.text, args)
+}
+
+// Tn traduce una clave al idioma especificado con la forma de plural de n,
+// según las reglas del idioma en el que se encontró la traducción
+func (t *Translator) Tn(lang, key string, n int, args Args) string {
+	msg, found, ok := t.lookup(lang, key)
+	if !ok {
+		return key
+	}
+	withCount := Args{"count": n}
+	for name, v := range args {
+		withCount[name] = v
+	}
+	return interpolate(msg.form(PluralCategory(found, n)), withCount)
+}
+
+// lookup busca la traducción de key en lang o, si no está, en el idioma
+// predeterminado. Devuelve también el idioma en el que la encontró.
+func (t *Translator) lookup(lang, key string) (message, string, bool) {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
 	// Primero intenta encontrar la traducción en el idioma solicitado
 	if translations, ok := t.translations[lang]; ok {
-		if val, ok := translations[key]; ok {
-			return val
+		if msg, ok := translations[key]; ok {
+			return msg, lang, true
 		}
 	}
 
 	// Si no se encuentra, intenta con el idioma predeterminado
 	if translations, ok := t.translations[t.defaultLang]; ok {
-		if val, ok := translations[key]; ok {
-			return val
+		if msg, ok := translations[key]; ok {
+			return msg, t.defaultLang, true
 		}
 	}
+	return message{}, "", false
+}
+
+// AvailableLanguages devuelve los códigos de idioma cargados, ordenados
+func (t *Translator) AvailableLanguages() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 
-	// Si todo falla, devuelve la clave como respaldo
-	return key
-} 
\ No newline at end of file
+	langs := make([]string, 0, len(t.translations))
+	for lang := range t.translations {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs
+}
+
+// Keys devuelve las claves del idioma lang, ordenadas
+func (t *Translator) Keys(lang string) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	keys := make([]string, 0, len(t.translations[lang]))
+	for key := range t.translations[lang] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MessagePlaceholders devuelve los marcadores de la clave key en el idioma
+// lang, sin buscar en el predeterminado. En los mensajes con plural reúne los
+// de todas sus formas.
+func (t *Translator) MessagePlaceholders(lang, key string) ([]string, bool) {
+	t.mu.RLock()
+	msg, ok := t.translations[lang][key]
+	t.mu.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	if msg.plural == nil {
+		return Placeholders(msg.text), true
+	}
+
+	categories := make([]string, 0, len(msg.plural))
+	for category := range msg.plural {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	var names []string
+	seen := make(map[string]bool)
+	for _, category := range categories {
+		for _, name := range Placeholders(msg.plural[category]) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names, true
+}
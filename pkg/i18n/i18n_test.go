@@ -0,0 +1,177 @@
+package i18n
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+// newTestTranslator crea un traductor con mensajes en español, inglés y ruso
+func newTestTranslator(t *testing.T) *Translator {
+	t.Helper()
+	translator, err := NewTranslator(fstest.MapFS{
+		"es/messages.json": {Data: []byte(`{
+			"greeting": "Hola, {name}",
+			"movies": {"one": "{count} película", "many": "{count} de películas", "other": "{count} películas"},
+			"only_es": {"one": "{count} cosa", "other": "{count} cosas"}
+		}`)},
+		"en/messages.json": {Data: []byte(`{
+			"greeting": "Hello, {name}",
+			"movies": {"one": "{count} movie in {where}", "other": "{count} movies in {where}"}
+		}`)},
+		"ru/messages.json": {Data: []byte(`{
+			"movies": {"one": "{count} фильм", "few": "{count} фильма", "many": "{count} фильмов", "other": "{count} фильма"}
+		}`)},
+	}, "es")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return translator
+}
+
+// Test para Tf y Tn: marcadores, formas de plural y respaldo
+func TestTranslator_TfTn(t *testing.T) {
+	translator := newTestTranslator(t)
+
+	tests := []struct {
+		got, expected string
+	}{
+		{translator.Tf("es", "greeting", Args{"name": "Ana"}), "Hola, Ana"},
+		{translator.Tf("en", "greeting", Args{"name": "<b>"}), "Hello, <b>"},
+		{translator.Tf("en", "greeting", nil), "Hello, {name}"},
+		{translator.Tn("es", "movies", 1, nil), "1 película"},
+		{translator.Tn("es", "movies", 0, nil), "0 películas"},
+		{translator.Tn("es", "movies", 1000000, nil), "1000000 de películas"},
+		{translator.Tn("en", "movies", 1, Args{"where": "cache"}), "1 movie in cache"},
+		{translator.Tn("en", "movies", 3, Args{"where": "cache"}), "3 movies in cache"},
+		{translator.Tn("ru", "movies", 21, nil), "21 фильм"},
+		{translator.Tn("ru", "movies", 3, nil), "3 фильма"},
+		{translator.Tn("ru", "movies", 11, nil), "11 фильмов"},
+		// Se usa la regla del idioma en que se encontró el mensaje
+		{translator.Tn("ru", "only_es", 21, nil), "21 cosas"},
+		{translator.T("es", "movies"), "{count} películas"},
+		{translator.Tn("es", "missing", 2, nil), "missing"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, tt.got)
+		}
+	}
+}
+
+// Test para Keys y MessagePlaceholders, que no recurren al idioma predeterminado
+func TestTranslator_KeysPlaceholders(t *testing.T) {
+	translator := newTestTranslator(t)
+
+	if keys := translator.Keys("en"); !reflect.DeepEqual(keys, []string{"greeting", "movies"}) {
+		t.Errorf("Expected keys [greeting movies], got %v", keys)
+	}
+	if names, ok := translator.MessagePlaceholders("en", "movies"); !ok || !reflect.DeepEqual(names, []string{"count", "where"}) {
+		t.Errorf("Expected placeholders [count where], got %v (%v)", names, ok)
+	}
+	if names, ok := translator.MessagePlaceholders("es", "greeting"); !ok || !reflect.DeepEqual(names, []string{"name"}) {
+		t.Errorf("Expected placeholders [name], got %v (%v)", names, ok)
+	}
+	if _, ok := translator.MessagePlaceholders("en", "only_es"); ok {
+		t.Error("Expected only_es to be missing in en")
+	}
+}
+
+// Test para NewEmptyTranslator: empieza sin traducciones y las lee al recargar
+func TestNewEmptyTranslator(t *testing.T) {
+	fsys := fstest.MapFS{"es/messages.json": {Data: []byte(`{"greeting": "Hola"`)}}
+	translator := NewEmptyTranslator(fsys, "es")
+	if got := translator.T("es", "greeting"); got != "greeting" {
+		t.Errorf("Expected the key as fallback, got %q", got)
+	}
+	if err := translator.Reload(); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+
+	fsys["es/messages.json"] = &fstest.MapFile{Data: []byte(`{"greeting": "Hola"}`)}
+	if err := translator.Reload(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := translator.T("es", "greeting"); got != "Hola" {
+		t.Errorf("Expected %q, got %q", "Hola", got)
+	}
+}
+
+// Test para NewTranslator: los plurales sin other o con categorías
+// desconocidas son un error
+func TestNewTranslator_InvalidPlural(t *testing.T) {
+	for _, data := range []string{
+		`{"movies": {"one": "{count} película"}}`,
+		`{"movies": {"one": "x", "some": "y", "other": "z"}}`,
+		`{"movies": 3}`,
+	} {
+		_, err := NewTranslator(fstest.MapFS{"es/messages.json": {Data: []byte(data)}}, "es")
+		if err == nil {
+			t.Errorf("Expected an error for %s", data)
+		}
+	}
+}
+
+// Test para PluralCategory con las reglas de CLDR de varios idiomas
+func TestPluralCategory(t *testing.T) {
+	tests := []struct {
+		lang     string
+		n        int
+		expected string
+	}{
+		{"en", 1, PluralOne},
+		{"en", 0, PluralOther},
+		{"en-GB", 2, PluralOther},
+		{"es", 1, PluralOne},
+		{"es-MX", 0, PluralOther},
+		{"es", 2000000, PluralMany},
+		{"fr", 0, PluralOne},
+		{"pt", 0, PluralOne},
+		{"pt-PT", 0, PluralOther},
+		{"ru", 1, PluralOne},
+		{"ru", 11, PluralMany},
+		{"ru", 22, PluralFew},
+		{"ru", 112, PluralMany},
+		{"pl", 1, PluralOne},
+		{"pl", 21, PluralMany},
+		{"pl", 24, PluralFew},
+		{"cs", 3, PluralFew},
+		{"cs", 5, PluralOther},
+		{"ar", 0, PluralZero},
+		{"ar", 2, PluralTwo},
+		{"ar", 103, PluralFew},
+		{"ar", 111, PluralMany},
+		{"ar", 100, PluralOther},
+		{"ja", 1, PluralOther},
+		{"xx", 1, PluralOne},
+		{"en", -1, PluralOne},
+	}
+	for _, tt := range tests {
+		if got := PluralCategory(tt.lang, tt.n); got != tt.expected {
+			t.Errorf("PluralCategory(%s, %d): expected %s, got %s", tt.lang, tt.n, tt.expected, got)
+		}
+	}
+}
+
+// Test para interpolate y Placeholders: llaves literales y marcadores no válidos
+func TestInterpolate(t *testing.T) {
+	args := Args{"a": 1, "b": "dos"}
+	tests := []struct {
+		text, expected string
+	}{
+		{"{a} y {b}", "1 y dos"},
+		{"{{a}} {a}", "{a} 1"},
+		{"{c} {a", "{c} {a"},
+		{"{no válido}", "{no válido}"},
+		{"sin marcadores", "sin marcadores"},
+	}
+	for _, tt := range tests {
+		if got := interpolate(tt.text, args); got != tt.expected {
+			t.Errorf("interpolate(%q): expected %q, got %q", tt.text, tt.expected, got)
+		}
+	}
+
+	if got := Placeholders("{a}, {{b}}, {count} y {a}"); !reflect.DeepEqual(got, []string{"a", "count"}) {
+		t.Errorf("Expected placeholders [a count], got %v", got)
+	}
+}
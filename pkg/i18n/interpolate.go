@@ -0,0 +1,83 @@
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// interpolate sustituye los marcadores {nombre} de text por los valores de
+// args. Los marcadores sin valor se dejan como están, para que se noten, y
+// {{ y }} escriben una llave literal.
+func interpolate(text string, args Args) string {
+	if !strings.ContainsAny(text, "{}") {
+		return text
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if (c == '{' || c == '}') && i+1 < len(text) && text[i+1] == c {
+			b.WriteByte(c)
+			i++
+			continue
+		}
+		if c != '{' {
+			b.WriteByte(c)
+			continue
+		}
+		end := strings.IndexByte(text[i:], '}')
+		if end < 0 {
+			b.WriteString(text[i:])
+			break
+		}
+		name := text[i+1 : i+end]
+		if v, ok := args[name]; ok && isPlaceholderName(name) {
+			fmt.Fprint(&b, v)
+		} else {
+			b.WriteString(text[i : i+end+1])
+		}
+		i += end
+	}
+	return b.String()
+}
+
+// Placeholders devuelve los nombres de los marcadores de text, sin repetir,
+// en el orden en que aparecen
+func Placeholders(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if (c == '{' || c == '}') && i+1 < len(text) && text[i+1] == c {
+			i++
+			continue
+		}
+		if c != '{' {
+			continue
+		}
+		end := strings.IndexByte(text[i:], '}')
+		if end < 0 {
+			break
+		}
+		if name := text[i+1 : i+end]; isPlaceholderName(name) && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+		i += end
+	}
+	return names
+}
+
+// isPlaceholderName indica si name es un nombre de marcador válido: letras,
+// dígitos y guiones bajos
+func isPlaceholderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
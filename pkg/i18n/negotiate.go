@@ -0,0 +1,77 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// languageRange es una entrada de Accept-Language con su peso
+type languageRange struct {
+	tag string
+	q   float64
+}
+
+// parseAcceptLanguage lee una cabecera Accept-Language (RFC 7231, sección
+// 5.3.5) y devuelve sus idiomas ordenados por preferencia. Las entradas con
+// q=0 o mal formadas se descartan.
+func parseAcceptLanguage(header string) []languageRange {
+	var ranges []languageRange
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil || v < 0 || v > 1 {
+				continue
+			}
+			q = v
+		}
+		if q == 0 {
+			continue
+		}
+		ranges = append(ranges, languageRange{tag: tag, q: q})
+	}
+	// A igual peso se respeta el orden de la cabecera
+	sort.SliceStable(ranges, func(i, j int) bool { return ranges[i].q > ranges[j].q })
+	return ranges
+}
+
+// MatchTag busca el idioma tag entre los disponibles. Si no está, prueba
+// quitando subetiquetas por la derecha: es-MX se sirve con es.
+func MatchTag(tag string, available []string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(strings.ReplaceAll(tag, "_", "-")))
+	for tag != "" {
+		for _, lang := range available {
+			if strings.EqualFold(lang, tag) {
+				return lang, true
+			}
+		}
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return "", false
+}
+
+// Negotiate elige, de los idiomas disponibles, el preferido según la cabecera
+// Accept-Language. Si ninguno es aceptable, o el preferido es el comodín *,
+// devuelve defaultLang.
+func Negotiate(header string, available []string, defaultLang string) string {
+	for _, r := range parseAcceptLanguage(header) {
+		if r.tag == "*" {
+			return defaultLang
+		}
+		if lang, ok := MatchTag(r.tag, available); ok {
+			return lang
+		}
+	}
+	return defaultLang
+}
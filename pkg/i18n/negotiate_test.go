@@ -0,0 +1,52 @@
+package i18n
+
+import "testing"
+
+// Test para Negotiate: pesos, orden, región y comodín
+func TestNegotiate(t *testing.T) {
+	available := []string{"en", "es"}
+	tests := []struct {
+		header   string
+		expected string
+	}{
+		{"", "es"},
+		{"en-US,en;q=0.9", "en"},
+		{"fr, en;q=0.5, es;q=0.8", "es"},
+		{"es-MX", "es"},
+		{"en-GB;q=0.7, es-AR;q=0.7", "en"},
+		{"EN", "en"},
+		{"en;q=0, es", "es"},
+		{"en;q=abc, es;q=0.1", "es"},
+		{"fr, de", "es"},
+		{"*, en;q=0.5", "es"},
+		{"zh-Hant-TW, en;q=0.1", "en"},
+	}
+	for _, tt := range tests {
+		if got := Negotiate(tt.header, available, "es"); got != tt.expected {
+			t.Errorf("Negotiate(%q): expected %s, got %s", tt.header, tt.expected, got)
+		}
+	}
+}
+
+// Test para MatchTag: coincidencia exacta antes que la del idioma base
+func TestMatchTag(t *testing.T) {
+	available := []string{"es", "pt", "pt-BR"}
+	tests := []struct {
+		tag      string
+		expected string
+		ok       bool
+	}{
+		{"pt-BR", "pt-BR", true},
+		{"pt_br", "pt-BR", true},
+		{"pt-PT", "pt", true},
+		{"es-419", "es", true},
+		{"fr", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := MatchTag(tt.tag, available)
+		if got != tt.expected || ok != tt.ok {
+			t.Errorf("MatchTag(%q): expected %q %v, got %q %v", tt.tag, tt.expected, tt.ok, got, ok)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package i18n
+
+import "strings"
+
+// Categorías de plural de CLDR
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+// pluralCategories son las categorías válidas en messages.json
+var pluralCategories = map[string]bool{
+	PluralZero: true, PluralOne: true, PluralTwo: true,
+	PluralFew: true, PluralMany: true, PluralOther: true,
+}
+
+// pluralRule devuelve la categoría de plural de una cantidad entera
+type pluralRule func(n int) string
+
+// Reglas de plural de CLDR para cantidades enteras, por idioma. Los idiomas
+// que no están aquí usan la de inglés (one para 1, other para el resto).
+// https://www.unicode.org/cldr/charts/latest/supplemental/language_plural_rules.html
+var pluralRules = map[string]pluralRule{
+	"es":    romanceRule(false),
+	"it":    romanceRule(false),
+	"fr":    romanceRule(true),
+	"pt":    romanceRule(true),
+	"pt-pt": romanceRule(false),
+	"ca":    romanceRule(false),
+	"ru":    eastSlavicRule,
+	"uk":    eastSlavicRule,
+	"be":    eastSlavicRule,
+	"pl":    polishRule,
+	"cs":    czechRule,
+	"sk":    czechRule,
+	"ar":    arabicRule,
+	"ja":    otherRule,
+	"zh":    otherRule,
+	"ko":    otherRule,
+	"vi":    otherRule,
+	"th":    otherRule,
+	"id":    otherRule,
+	"tr":    englishRule,
+	"en":    englishRule,
+	"de":    englishRule,
+	"nl":    englishRule,
+	"sv":    englishRule,
+}
+
+// PluralCategory devuelve la categoría de plural de n en el idioma lang
+func PluralCategory(lang string, n int) string {
+	if n < 0 {
+		n = -n
+	}
+	return ruleFor(lang)(n)
+}
+
+// ruleFor busca la regla de lang, o la de su idioma base si no tiene propia
+func ruleFor(lang string) pluralRule {
+	tag := strings.ToLower(strings.ReplaceAll(lang, "_", "-"))
+	for tag != "" {
+		if rule, ok := pluralRules[tag]; ok {
+			return rule
+		}
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	return englishRule
+}
+
+func englishRule(n int) string {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func otherRule(n int) string {
+	return PluralOther
+}
+
+// romanceRule es la regla de español, francés, italiano y portugués: many para
+// los millones exactos. zeroIsOne indica que 0 también es one, como en francés.
+func romanceRule(zeroIsOne bool) pluralRule {
+	return func(n int) string {
+		switch {
+		case n == 1 || n == 0 && zeroIsOne:
+			return PluralOne
+		case n != 0 && n%1000000 == 0:
+			return PluralMany
+		}
+		return PluralOther
+	}
+}
+
+// eastSlavicRule es la regla de ruso, ucraniano y bielorruso
+func eastSlavicRule(n int) string {
+	mod10, mod100 := n%10, n%100
+	switch {
+	case mod10 == 1 && mod100 != 11:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+// polishRule es la regla de polaco
+func polishRule(n int) string {
+	mod10, mod100 := n%10, n%100
+	switch {
+	case n == 1:
+		return PluralOne
+	case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+// czechRule es la regla de checo y eslovaco; many solo se usa con decimales
+func czechRule(n int) string {
+	switch {
+	case n == 1:
+		return PluralOne
+	case n >= 2 && n <= 4:
+		return PluralFew
+	}
+	return PluralOther
+}
+
+// arabicRule es la regla de árabe
+func arabicRule(n int) string {
+	mod100 := n % 100
+	switch {
+	case n == 0:
+		return PluralZero
+	case n == 1:
+		return PluralOne
+	case n == 2:
+		return PluralTwo
+	case mod100 >= 3 && mod100 <= 10:
+		return PluralFew
+	case mod100 >= 11:
+		return PluralMany
+	}
+	return PluralOther
+}
@@ -0,0 +1,75 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// contextKey es el tipo de las claves de contexto de este paquete
+type contextKey struct{}
+
+// requestIDKey guarda el identificador de la petición en el contexto
+var requestIDKey = contextKey{}
+
+// WithRequestID devuelve un contexto que lleva el identificador de petición
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID devuelve el identificador de petición del contexto, o "" si no hay
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// ParseLevel convierte un nombre de nivel (debug, info, warn, error) en slog.Level
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("nivel de log no válido %q: %w", s, err)
+	}
+	return level, nil
+}
+
+// NewHandler crea un handler de slog en formato "text" o "json" que añade a
+// cada registro el identificador de petición presente en el contexto
+func NewHandler(w io.Writer, format string, level slog.Leveler) (slog.Handler, error) {
+	opts := &slog.HandlerOptions{Level: level}
+
+	var h slog.Handler
+	switch format {
+	case "json":
+		h = slog.NewJSONHandler(w, opts)
+	case "text", "":
+		h = slog.NewTextHandler(w, opts)
+	default:
+		return nil, fmt.Errorf("formato de log no válido %q (use text o json)", format)
+	}
+	return &contextHandler{Handler: h}, nil
+}
+
+// contextHandler añade atributos tomados del contexto a cada registro
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	ctx := WithRequestID(context.Background(), "abc123")
+	if id := RequestID(ctx); id != "abc123" {
+		t.Errorf("Expected abc123, got %s", id)
+	}
+	if id := RequestID(context.Background()); id != "" {
+		t.Errorf("Expected empty request ID, got %s", id)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	level, err := ParseLevel("debug")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if level != slog.LevelDebug {
+		t.Errorf("Expected debug level, got %s", level)
+	}
+
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level, got nil")
+	}
+}
+
+func TestNewHandler_JSONWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewHandler(&buf, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(h).With("component", "test")
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "hola", "title", "Star Wars")
+	logger.DebugContext(ctx, "no debería salir")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a single JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["request_id"] != "req-1" {
+		t.Errorf("Expected request_id=req-1, got %v", record["request_id"])
+	}
+	if record["component"] != "test" || record["title"] != "Star Wars" {
+		t.Errorf("Expected attributes to be kept, got %v", record)
+	}
+}
+
+func TestNewHandler_InvalidFormat(t *testing.T) {
+	if _, err := NewHandler(&bytes.Buffer{}, "xml", slog.LevelInfo); err == nil {
+		t.Error("Expected an error for an unknown format, got nil")
+	}
+}
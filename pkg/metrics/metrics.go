@@ -0,0 +1,369 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets son los límites de histograma (en segundos) usados por defecto
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default es el registro global donde se declaran las métricas de la aplicación
+var Default = NewRegistry()
+
+// collector lo implementan las métricas que saben escribirse en formato texto
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry agrupa métricas y las expone en el formato de texto de Prometheus
+type Registry struct {
+	mu         sync.Mutex
+	collectors map[string]collector
+}
+
+// NewRegistry crea un registro vacío
+func NewRegistry() *Registry {
+	return &Registry{
+		collectors: make(map[string]collector),
+	}
+}
+
+// register añade una métrica al registro; los nombres duplicados son un error de programación
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.collectors[c.name()]; ok {
+		panic("metrics: métrica registrada dos veces: " + c.name())
+	}
+	r.collectors[c.name()] = c
+}
+
+// WriteText escribe todas las métricas en el formato de exposición de Prometheus
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	names := make([]string, 0, len(r.collectors))
+	for name := range r.collectors {
+		names = append(names, name)
+	}
+	collectors := make([]collector, 0, len(names))
+	sort.Strings(names)
+	for _, name := range names {
+		collectors = append(collectors, r.collectors[name])
+	}
+	r.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	for _, c := range collectors {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler devuelve un handler HTTP que sirve las métricas del registro
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteText(w)
+	})
+}
+
+// desc contiene los datos comunes a todas las métricas
+type desc struct {
+	metricName string
+	help       string
+	labels     []string
+}
+
+func (d *desc) name() string { return d.metricName }
+
+// writeHeader escribe las líneas HELP y TYPE de la métrica
+func (d *desc) writeHeader(w *bufio.Writer, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", d.metricName, escapeHelp(d.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", d.metricName, kind)
+}
+
+// key convierte los valores de las etiquetas en la clave interna de la serie
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s espera %d etiquetas, recibió %d", d.metricName, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// labelPairs formatea las etiquetas de una serie, con pares extra opcionales (como le)
+func (d *desc) labelPairs(key string, extra ...string) string {
+	var pairs []string
+	if len(d.labels) > 0 {
+		values := strings.Split(key, "\xff")
+		for i, label := range d.labels {
+			pairs = append(pairs, label+`="`+escapeLabel(values[i])+`"`)
+		}
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		pairs = append(pairs, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// CounterVec es un contador monotónico con etiquetas
+type CounterVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec crea y registra un contador en el registro
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		desc:   desc{metricName: name, help: help, labels: labels},
+		values: make(map[string]float64),
+	}
+	r.register(c)
+	return c
+}
+
+// NewCounterVec crea un contador en el registro global
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// Inc incrementa en uno la serie con los valores de etiqueta indicados
+func (c *CounterVec) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add suma v (que no puede ser negativo) a la serie indicada
+func (c *CounterVec) Add(v float64, values ...string) {
+	if v < 0 {
+		panic("metrics: un contador no puede decrementarse")
+	}
+	key := c.key(values)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+// Value devuelve el valor actual de la serie indicada
+func (c *CounterVec) Value(values ...string) float64 {
+	key := c.key(values)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.values[key]
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.writeHeader(w, "counter")
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Un contador sin etiquetas se expone siempre, aunque valga cero
+	if len(c.labels) == 0 {
+		fmt.Fprintf(w, "%s %s\n", c.metricName, formatFloat(c.values[""]))
+		return
+	}
+	for _, key := range sortedKeys(c.values) {
+		fmt.Fprintf(w, "%s%s %s\n", c.metricName, c.labelPairs(key), formatFloat(c.values[key]))
+	}
+}
+
+// GaugeVec es un valor que puede subir y bajar, con etiquetas
+type GaugeVec struct {
+	desc
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewGaugeVec crea y registra un gauge en el registro
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{
+		desc:   desc{metricName: name, help: help, labels: labels},
+		values: make(map[string]float64),
+	}
+	r.register(g)
+	return g
+}
+
+// NewGaugeVec crea un gauge en el registro global
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+// Set fija el valor de la serie indicada
+func (g *GaugeVec) Set(v float64, values ...string) {
+	key := g.key(values)
+	g.mu.Lock()
+	g.values[key] = v
+	g.mu.Unlock()
+}
+
+// Add suma v (positivo o negativo) a la serie indicada
+func (g *GaugeVec) Add(v float64, values ...string) {
+	key := g.key(values)
+	g.mu.Lock()
+	g.values[key] += v
+	g.mu.Unlock()
+}
+
+// Inc incrementa en uno la serie indicada
+func (g *GaugeVec) Inc(values ...string) { g.Add(1, values...) }
+
+// Dec decrementa en uno la serie indicada
+func (g *GaugeVec) Dec(values ...string) { g.Add(-1, values...) }
+
+// Value devuelve el valor actual de la serie indicada
+func (g *GaugeVec) Value(values ...string) float64 {
+	key := g.key(values)
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.values[key]
+}
+
+func (g *GaugeVec) write(w *bufio.Writer) {
+	g.writeHeader(w, "gauge")
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if len(g.labels) == 0 {
+		fmt.Fprintf(w, "%s %s\n", g.metricName, formatFloat(g.values[""]))
+		return
+	}
+	for _, key := range sortedKeys(g.values) {
+		fmt.Fprintf(w, "%s%s %s\n", g.metricName, g.labelPairs(key), formatFloat(g.values[key]))
+	}
+}
+
+// HistogramVec acumula observaciones en cubetas, con etiquetas
+type HistogramVec struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	series  map[string]*histogramSeries
+}
+
+// histogramSeries son los contadores de una serie concreta del histograma
+type histogramSeries struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// NewHistogramVec crea y registra un histograma; si buckets es nil usa DefaultBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if buckets == nil {
+		buckets = DefaultBuckets
+	}
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+
+	h := &HistogramVec{
+		desc:    desc{metricName: name, help: help, labels: labels},
+		buckets: sorted,
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(h)
+	return h
+}
+
+// NewHistogramVec crea un histograma en el registro global
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// Observe añade una observación a la serie indicada
+func (h *HistogramVec) Observe(v float64, values ...string) {
+	key := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	for i, upper := range h.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.sum += v
+	s.count++
+}
+
+// Count devuelve el número de observaciones de la serie indicada
+func (h *HistogramVec) Count(values ...string) uint64 {
+	key := h.key(values)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s, ok := h.series[key]; ok {
+		return s.count
+	}
+	return 0
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.writeHeader(w, "histogram")
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.series))
+	for key := range h.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := h.series[key]
+		for i, upper := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(key, "le", formatFloat(upper)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.metricName, h.labelPairs(key, "le", "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.metricName, h.labelPairs(key), formatFloat(s.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.metricName, h.labelPairs(key), s.count)
+	}
+}
+
+// sortedKeys devuelve las claves del mapa ordenadas para una salida estable
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatFloat formatea un valor como lo espera Prometheus
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeLabel escapa barras, comillas y saltos de línea en valores de etiqueta
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// escapeHelp escapa barras y saltos de línea en el texto de ayuda
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,135 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCounterVec(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "Peticiones de prueba.", "route", "status")
+
+	c.Inc("/search", "200")
+	c.Inc("/search", "200")
+	c.Add(3, "/movie", "500")
+
+	if v := c.Value("/search", "200"); v != 2 {
+		t.Errorf("Expected 2, got %v", v)
+	}
+
+	var buf bytes.Buffer
+	if err := r.WriteText(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP test_requests_total Peticiones de prueba.
+# TYPE test_requests_total counter
+test_requests_total{route="/movie",status="500"} 3
+test_requests_total{route="/search",status="200"} 2
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected exposition:\n%s", buf.String())
+	}
+}
+
+func TestCounterVec_WithoutLabels(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_hits_total", "Aciertos.")
+
+	var buf bytes.Buffer
+	r.WriteText(&buf)
+
+	// Un contador sin etiquetas se expone aunque valga cero
+	if !strings.Contains(buf.String(), "test_hits_total 0\n") {
+		t.Errorf("Expected zero-valued counter, got:\n%s", buf.String())
+	}
+}
+
+func TestGaugeVec(t *testing.T) {
+	r := NewRegistry()
+	g := r.NewGaugeVec("test_in_flight", "En curso.")
+
+	g.Inc()
+	g.Inc()
+	g.Dec()
+
+	if v := g.Value(); v != 1 {
+		t.Errorf("Expected 1, got %v", v)
+	}
+
+	g.Set(7)
+	var buf bytes.Buffer
+	r.WriteText(&buf)
+	if !strings.Contains(buf.String(), "# TYPE test_in_flight gauge\ntest_in_flight 7\n") {
+		t.Errorf("Unexpected exposition:\n%s", buf.String())
+	}
+}
+
+func TestHistogramVec(t *testing.T) {
+	r := NewRegistry()
+	h := r.NewHistogramVec("test_duration_seconds", "Latencia.", []float64{0.1, 1}, "op")
+
+	h.Observe(0.05, "get")
+	h.Observe(0.5, "get")
+	h.Observe(5, "get")
+
+	if n := h.Count("get"); n != 3 {
+		t.Errorf("Expected count 3, got %d", n)
+	}
+
+	var buf bytes.Buffer
+	r.WriteText(&buf)
+
+	expected := `# HELP test_duration_seconds Latencia.
+# TYPE test_duration_seconds histogram
+test_duration_seconds_bucket{op="get",le="0.1"} 1
+test_duration_seconds_bucket{op="get",le="1"} 2
+test_duration_seconds_bucket{op="get",le="+Inf"} 3
+test_duration_seconds_sum{op="get"} 5.55
+test_duration_seconds_count{op="get"} 3
+`
+	if buf.String() != expected {
+		t.Errorf("Unexpected exposition:\n%s", buf.String())
+	}
+}
+
+func TestEscapeLabel(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_escape_total", "Escape.", "value")
+	c.Inc("a\"b\\c\nd")
+
+	var buf bytes.Buffer
+	r.WriteText(&buf)
+	if !strings.Contains(buf.String(), `test_escape_total{value="a\"b\\c\nd"} 1`) {
+		t.Errorf("Expected escaped label, got:\n%s", buf.String())
+	}
+}
+
+func TestRegister_Duplicate(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_dup_total", "Duplicado.")
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected a panic when registering the same metric twice")
+		}
+	}()
+	r.NewGaugeVec("test_dup_total", "Duplicado.")
+}
+
+func TestHandler(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounterVec("test_handler_total", "Handler.")
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected Content-Type %s", ct)
+	}
+	if !strings.Contains(w.Body.String(), "test_handler_total 0") {
+		t.Errorf("Expected metric in body, got:\n%s", w.Body.String())
+	}
+}
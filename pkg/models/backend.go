@@ -0,0 +1,241 @@
+package models
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+)
+
+// ErrCacheMiss indica que la clave no está en el almacenamiento
+var ErrCacheMiss = errors.New("no está en la caché")
+
+// CacheBackend es un almacenamiento de películas por clave. Hace de segundo
+// nivel (L2) bajo la caché en memoria del modelo.
+type CacheBackend interface {
+	// Get devuelve la película guardada bajo key, o ErrCacheMiss
+	Get(ctx context.Context, key string) (*CachedMovie, error)
+	Set(ctx context.Context, key string, movie *CachedMovie) error
+	// Delete elimina key; no es un error que no exista
+	Delete(ctx context.Context, key string) error
+	// Clear elimina todas las películas
+	Clear(ctx context.Context) error
+}
+
+// Subdirectorio de DiskBackend con los archivos que no se pudieron leer ni migrar
+const quarantineDir = "quarantine"
+
+// DiskBackend guarda cada clave en un archivo JSON dentro de un directorio
+type DiskBackend struct {
+	dir string
+}
+
+// MigrateStats resume el resultado de DiskBackend.Migrate
+type MigrateStats struct {
+	Current     int // Ya tenían el esquema actual
+	Migrated    int // Se convirtieron al esquema actual
+	Newer       int // Tienen un esquema más reciente y se dejaron como estaban
+	Quarantined int // No se pudieron leer ni migrar y se apartaron
+}
+
+// diskRecord es el contenido de un archivo de DiskBackend
+type diskRecord struct {
+	Schema   int         `json:"schema"`
+	Key      string      `json:"key"`
+	CachedAt time.Time   `json:"cached_at"`
+	Movie    *omdb.Movie `json:"movie"`
+}
+
+// NewDiskBackend crea un almacenamiento en dir, creando el directorio si no existe
+func NewDiskBackend(dir string) (*DiskBackend, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskBackend{dir: dir}, nil
+}
+
+// path devuelve el archivo de key. El nombre es un hash para admitir cualquier
+// título sin preocuparse de caracteres no válidos en el sistema de archivos.
+func (d *DiskBackend) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(d.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get lee la película guardada bajo key. Los archivos con un esquema antiguo
+// se migran y se reescriben; los que no se pueden leer se ponen en cuarentena.
+func (d *DiskBackend) Get(ctx context.Context, key string) (*CachedMovie, error) {
+	path := d.path(key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rec diskRecord
+	migrated, err := upgradeRecord(data, 0, &rec)
+	if errors.Is(err, ErrSchemaTooNew) {
+		// Lo escribió una versión más reciente: se deja para ella
+		return nil, ErrCacheMiss
+	}
+	if err == nil && rec.Movie == nil {
+		err = errors.New("falta movie")
+	}
+	if err != nil {
+		if qerr := d.quarantine(path); qerr != nil {
+			return nil, fmt.Errorf("%s: %w", key, errors.Join(err, qerr))
+		}
+		return nil, fmt.Errorf("%s: registro en cuarentena: %w", key, err)
+	}
+	// Dos claves con el mismo hash son muy improbables, pero no imposibles
+	if rec.Key != key {
+		return nil, ErrCacheMiss
+	}
+
+	movie := &CachedMovie{Movie: rec.Movie, CachedAt: rec.CachedAt}
+	if migrated {
+		// Si falla, se volverá a migrar en la próxima lectura
+		d.Set(ctx, key, movie)
+	}
+	return movie, nil
+}
+
+// Set guarda la película bajo key con el esquema actual
+func (d *DiskBackend) Set(ctx context.Context, key string, movie *CachedMovie) error {
+	return d.write(d.path(key), diskRecord{Schema: schemaVersion, Key: key, CachedAt: movie.CachedAt, Movie: movie.Movie})
+}
+
+// write guarda rec en path. Se escribe en un temporal que luego se renombra,
+// para que una lectura nunca vea un archivo a medias.
+func (d *DiskBackend) write(path string, rec diskRecord) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(d.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// Delete elimina el archivo de key
+func (d *DiskBackend) Delete(ctx context.Context, key string) error {
+	err := os.Remove(d.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// Clear elimina todos los archivos de películas del directorio
+func (d *DiskBackend) Clear(ctx context.Context) error {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if err := os.Remove(filepath.Join(d.dir, e.Name())); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// quarantine aparta el archivo path al subdirectorio de cuarentena, donde se
+// puede revisar, en lugar de borrarlo o de fallar cada vez que se lee
+func (d *DiskBackend) quarantine(path string) error {
+	dir := filepath.Join(d.dir, quarantineDir)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	slog.Warn("cache record quarantined", "file", filepath.Base(path), "dir", dir)
+	return os.Rename(path, filepath.Join(dir, filepath.Base(path)))
+}
+
+// Migrate convierte al esquema actual todos los archivos del directorio y pone
+// en cuarentena los que no se pueden leer. Sirve para migrar de una vez en
+// lugar de hacerlo poco a poco en cada lectura.
+func (d *DiskBackend) Migrate(ctx context.Context) (MigrateStats, error) {
+	var stats MigrateStats
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return stats, err
+	}
+
+	var errs []error
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(d.dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		var rec diskRecord
+		migrated, err := upgradeRecord(data, 0, &rec)
+		if errors.Is(err, ErrSchemaTooNew) {
+			stats.Newer++
+			continue
+		}
+		if err == nil && (rec.Movie == nil || rec.Key == "") {
+			err = errors.New("faltan key o movie")
+		}
+		if err != nil {
+			if err := d.quarantine(path); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			stats.Quarantined++
+			continue
+		}
+		if !migrated {
+			stats.Current++
+			continue
+		}
+
+		// La migración puede cambiar la clave y con ella el nombre del archivo
+		rec.Schema = schemaVersion
+		target := d.path(rec.Key)
+		if err := d.write(target, rec); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if target != path {
+			os.Remove(path)
+		}
+		stats.Migrated++
+	}
+	return stats, errors.Join(errs...)
+}
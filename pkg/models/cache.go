@@ -0,0 +1,336 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+)
+
+// CacheEntry describe una película guardada en la caché, para administrarla
+type CacheEntry struct {
+	// Key es la clave principal de la entrada: "id:" + ImdbID, o el título si
+	// OMDB no devolvió ID. Es la que acepta Evict.
+	Key      string
+	Title    string
+	ImdbID   string
+	Aliases  []string
+	CachedAt time.Time
+	Age      time.Duration
+	Hits     int
+	// LastAccess es el último acierto, o CachedAt si no ha tenido ninguno
+	LastAccess time.Time
+	Size       int
+	// Compressed indica que la película está guardada comprimida por estar fría
+	Compressed bool
+}
+
+// WarmResult es el resultado de precargar una película en la caché
+type WarmResult struct {
+	Query  string
+	Title  string
+	ImdbID string
+	// Cached indica que la película ya estaba en la caché
+	Cached bool
+	Err    error
+}
+
+// primaryKey devuelve la clave principal de la entrada guardada bajo key
+func (e *cacheEntry) primaryKey(key string) string {
+	if e.imdbID != "" {
+		return idKey(e.imdbID)
+	}
+	return key
+}
+
+// movieSize estima lo que ocupa una película en la caché por el tamaño de su JSON
+func movieSize(movie *omdb.Movie) int {
+	data, err := json.Marshal(movie)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// CacheEntries devuelve las películas de la caché, de la más reciente a la más
+// antigua. Si query no está vacía, solo las que la contienen en el título, el ID
+// o alguno de sus alias.
+func (m *MovieModel) CacheEntries(query string) []CacheEntry {
+	now := time.Now()
+	query = strings.ToLower(strings.TrimSpace(query))
+
+	m.mu.RLock()
+	groups := make(map[string]*CacheEntry)
+	for key, e := range m.cache {
+		primary := e.primaryKey(key)
+		ce, ok := groups[primary]
+		if !ok {
+			// La entrada guardada bajo la clave principal es la vigente
+			p := m.cache[primary]
+			if p == nil {
+				p = e
+			}
+			ce = &CacheEntry{
+				Key:        primary,
+				CachedAt:   p.cachedAt,
+				Age:        now.Sub(p.cachedAt).Truncate(time.Second),
+				Hits:       int(p.hits.Load()),
+				LastAccess: p.lastAccessed(),
+				Size:       p.size,
+			}
+			ce.Title = p.title
+			ce.ImdbID = p.imdbID
+			ce.Compressed = p.compressed != nil
+			groups[primary] = ce
+		}
+		if key != primary {
+			ce.Aliases = append(ce.Aliases, key)
+		}
+	}
+	m.mu.RUnlock()
+
+	entries := make([]CacheEntry, 0, len(groups))
+	for _, ce := range groups {
+		if query != "" && !ce.matches(query) {
+			continue
+		}
+		sort.Strings(ce.Aliases)
+		entries = append(entries, *ce)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if !entries[i].CachedAt.Equal(entries[j].CachedAt) {
+			return entries[i].CachedAt.After(entries[j].CachedAt)
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// matches indica si la entrada contiene query (ya en minúsculas)
+func (ce *CacheEntry) matches(query string) bool {
+	if strings.Contains(strings.ToLower(ce.Title), query) || strings.Contains(strings.ToLower(ce.ImdbID), query) {
+		return true
+	}
+	for _, alias := range ce.Aliases {
+		if strings.Contains(strings.ToLower(alias), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// store guarda entry bajo su clave principal y bajo los alias indicados. Los
+// alias que ya apuntaban a la película pasan a la nueva entrada, y un alias que
+// apuntaba a otra película deja de hacerlo. Se llama con m.mu tomado.
+func (m *MovieModel) store(entry *cacheEntry, primary string, aliases ...string) {
+	if m.aliases == nil {
+		m.aliases = make(map[string][]string)
+	}
+	if old, ok := m.cache[primary]; ok {
+		m.account(old, -1)
+	}
+	m.account(entry, 1)
+	m.cache[primary] = entry
+	// Toda película tiene su lista de alias, aunque esté vacía
+	m.aliases[primary] = m.aliases[primary]
+	for _, alias := range m.aliases[primary] {
+		m.cache[alias] = entry
+	}
+
+	for _, alias := range aliases {
+		if alias == "" || alias == primary || slices.Contains(m.aliases[primary], alias) {
+			continue
+		}
+		if old, ok := m.cache[alias]; ok {
+			m.unlinkAlias(old, alias)
+		}
+		m.cache[alias] = entry
+		m.aliases[primary] = append(m.aliases[primary], alias)
+	}
+}
+
+// unlinkAlias quita alias de la película old, a la que apuntaba. Si era su
+// clave principal, la película sale de la caché. Se llama con m.mu tomado.
+func (m *MovieModel) unlinkAlias(old *cacheEntry, alias string) {
+	oldPrimary := old.primaryKey(alias)
+	if oldPrimary == alias {
+		m.remove(alias)
+		m.countEvictions("replaced", 1)
+		return
+	}
+	m.aliases[oldPrimary] = slices.DeleteFunc(m.aliases[oldPrimary], func(a string) bool {
+		return a == alias
+	})
+}
+
+// remove elimina la película con clave principal primary y todos sus alias. Se
+// llama con m.mu tomado.
+func (m *MovieModel) remove(primary string) {
+	if e, ok := m.cache[primary]; ok {
+		m.account(e, -1)
+	}
+	for _, alias := range m.aliases[primary] {
+		delete(m.cache, alias)
+	}
+	delete(m.aliases, primary)
+	delete(m.cache, primary)
+}
+
+// Evict elimina de la caché la película guardada bajo key, que puede ser su
+// clave principal o cualquiera de sus títulos, junto con todos sus alias.
+// Devuelve false si no estaba.
+func (m *MovieModel) Evict(key string) bool {
+	m.mu.Lock()
+	e, ok := m.cache[key]
+	if !ok {
+		key = titleKey(key)
+		if e, ok = m.cache[key]; !ok {
+			m.mu.Unlock()
+			return false
+		}
+	}
+	primary := e.primaryKey(key)
+	keys := append([]string{primary}, m.aliases[primary]...)
+	m.remove(primary)
+	m.countEvictions("admin", 1)
+	m.mu.Unlock()
+
+	// L2 puede estar en disco: no se bloquea la caché mientras se borra
+	m.l2Delete(context.Background(), keys...)
+	return true
+}
+
+// Flush vacía la caché y devuelve cuántas películas había
+func (m *MovieModel) Flush() int {
+	m.mu.Lock()
+	primaries := make(map[string]bool)
+	for k, e := range m.cache {
+		primaries[e.primaryKey(k)] = true
+	}
+	m.cache = make(map[string]*cacheEntry)
+	m.aliases = make(map[string][]string)
+	m.bytes, m.entries, m.compressed = 0, 0, 0
+
+	cacheEntries.Set(0)
+	cacheBytes.Set(0)
+	m.countEvictions("flush", len(primaries))
+	m.mu.Unlock()
+
+	// Vaciar L2 puede recorrer todo el directorio: fuera del bloqueo
+	m.l2Clear(context.Background())
+	return len(primaries)
+}
+
+// WarmOptions configura una precarga de la caché
+type WarmOptions struct {
+	// Concurrency es el número de películas que se cargan a la vez (1 si es menor)
+	Concurrency int
+	// Rate limita las consultas por segundo para no superar el límite de OMDB
+	// (0 sin límite)
+	Rate float64
+	// OnResult, si no es nil, se llama tras cada película con el progreso acumulado.
+	// Se llama desde varias goroutines, pero nunca a la vez.
+	OnResult func(result WarmResult, progress WarmProgress)
+}
+
+// WarmProgress es el progreso de una precarga
+type WarmProgress struct {
+	Total  int
+	Done   int
+	Failed int
+}
+
+// Warm precarga en la caché las películas indicadas por título o por ID de IMDb,
+// de una en una. Las líneas vacías se ignoran.
+func (m *MovieModel) Warm(ctx context.Context, queries []string) []WarmResult {
+	return m.WarmWithOptions(ctx, queries, WarmOptions{Concurrency: 1})
+}
+
+// WarmWithOptions precarga las películas con la concurrencia y el ritmo indicados.
+// Los resultados mantienen el orden de queries.
+func (m *MovieModel) WarmWithOptions(ctx context.Context, queries []string, opts WarmOptions) []WarmResult {
+	var pending []string
+	for _, q := range queries {
+		if q = strings.TrimSpace(q); q != "" {
+			pending = append(pending, q)
+		}
+	}
+
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	var tick <-chan time.Time
+	if opts.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / opts.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	results := make([]WarmResult, len(pending))
+	indexes := make(chan int)
+	var mu sync.Mutex
+	progress := WarmProgress{Total: len(pending)}
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				results[i] = m.warmOne(ctx, pending[i], tick)
+
+				mu.Lock()
+				progress.Done++
+				if results[i].Err != nil {
+					progress.Failed++
+				}
+				if opts.OnResult != nil {
+					opts.OnResult(results[i], progress)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range pending {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results
+}
+
+// warmOne carga una película en la caché, esperando antes su turno en tick si no es nil
+func (m *MovieModel) warmOne(ctx context.Context, q string, tick <-chan time.Time) WarmResult {
+	if tick != nil {
+		select {
+		case <-tick:
+		case <-ctx.Done():
+		}
+	}
+	if ctx.Err() != nil {
+		return WarmResult{Query: q, Err: ctx.Err()}
+	}
+
+	var cached *CachedMovie
+	var err error
+	if omdb.IsIMDbID(q) {
+		cached, err = m.GetByID(ctx, q)
+	} else {
+		cached, err = m.GetByTitle(ctx, q)
+	}
+
+	result := WarmResult{Query: q, Err: err}
+	if err == nil {
+		result.Title = cached.Movie.Title
+		result.ImdbID = cached.Movie.ImdbID
+		result.Cached = cached.FromCache
+	}
+	return result
+}
@@ -0,0 +1,331 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+)
+
+// newCacheTestModel crea un modelo cuyo cliente conoce dos películas
+func newCacheTestModel() *MovieModel {
+	movies := map[string]*omdb.Movie{
+		"star wars": {Title: "Star Wars", ImdbID: "tt0076759"},
+		"alien":     {Title: "Alien", ImdbID: "tt0078748"},
+	}
+	return &MovieModel{
+		client: &MockClient{
+			GetMovieByTitleFunc: func(title string) (*omdb.Movie, error) {
+				if m, ok := movies[title]; ok {
+					return m, nil
+				}
+				return nil, omdb.ErrNotFound
+			},
+			GetMovieByIDFunc: func(imdbID string) (*omdb.Movie, error) {
+				for _, m := range movies {
+					if m.ImdbID == imdbID {
+						return m, nil
+					}
+				}
+				return nil, omdb.ErrNotFound
+			},
+		},
+		cache: make(map[string]*cacheEntry),
+	}
+}
+
+// Test para CacheEntries: una entrada por película, con sus alias y aciertos
+func TestCacheEntries(t *testing.T) {
+	model := newCacheTestModel()
+	ctx := context.Background()
+	model.GetByTitle(ctx, "star wars")
+	model.GetByID(ctx, "tt0076759")
+	model.GetByTitle(ctx, "alien")
+
+	entries := model.CacheEntries("")
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(entries))
+	}
+
+	var starWars *CacheEntry
+	for i := range entries {
+		if entries[i].ImdbID == "tt0076759" {
+			starWars = &entries[i]
+		}
+	}
+	if starWars == nil {
+		t.Fatal("Expected Star Wars to be listed")
+	}
+	if starWars.Key != "id:tt0076759" || len(starWars.Aliases) != 1 || starWars.Aliases[0] != "star wars" {
+		t.Errorf("Expected primary key and title alias, got %+v", starWars)
+	}
+	if starWars.Hits != 1 {
+		t.Errorf("Expected 1 hit, got %d", starWars.Hits)
+	}
+	if starWars.Size == 0 {
+		t.Error("Expected entry size to be set")
+	}
+
+	if found := model.CacheEntries("ALI"); len(found) != 1 || found[0].Title != "Alien" {
+		t.Errorf("Expected search to find Alien, got %+v", found)
+	}
+}
+
+// Test para Evict y Flush
+func TestEvictAndFlush(t *testing.T) {
+	model := newCacheTestModel()
+	ctx := context.Background()
+	model.GetByTitle(ctx, "star wars")
+	model.GetByTitle(ctx, "alien")
+
+	if !model.Evict("id:tt0076759") {
+		t.Fatal("Expected entry to be evicted")
+	}
+	if _, ok := model.cache["star wars"]; ok {
+		t.Error("Expected title alias to be evicted with the entry")
+	}
+	if model.Evict("id:tt0076759") {
+		t.Error("Expected second eviction to report a missing entry")
+	}
+
+	if n := model.Flush(); n != 1 {
+		t.Errorf("Expected 1 flushed entry, got %d", n)
+	}
+	if len(model.cache) != 0 {
+		t.Errorf("Expected empty cache, got %d keys", len(model.cache))
+	}
+}
+
+// Test para Warm: títulos e IDs, con las películas ya cachadas y los errores
+func TestWarm(t *testing.T) {
+	model := newCacheTestModel()
+	model.GetByTitle(context.Background(), "alien")
+
+	results := model.Warm(context.Background(), []string{"tt0076759", "", "  alien ", "unknown"})
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	if results[0].Title != "Star Wars" || results[0].Cached {
+		t.Errorf("Expected Star Wars fetched by ID, got %+v", results[0])
+	}
+	if results[1].Query != "alien" || !results[1].Cached {
+		t.Errorf("Expected Alien already cached, got %+v", results[1])
+	}
+	if !errors.Is(results[2].Err, omdb.ErrNotFound) {
+		t.Errorf("Expected not found error, got %v", results[2].Err)
+	}
+	if len(model.CacheEntries("")) != 2 {
+		t.Error("Expected both movies to be cached")
+	}
+}
+
+// Test para WarmWithOptions: concurrencia limitada, orden de los resultados y progreso
+func TestWarmWithOptions(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	model := &MovieModel{
+		client: &MockClient{
+			GetMovieByTitleFunc: func(title string) (*omdb.Movie, error) {
+				mu.Lock()
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+
+				time.Sleep(10 * time.Millisecond)
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+				if title == "missing" {
+					return nil, omdb.ErrNotFound
+				}
+				return &omdb.Movie{Title: title, ImdbID: "tt00000" + title}, nil
+			},
+		},
+		cache: make(map[string]*cacheEntry),
+	}
+
+	queries := []string{"01", "02", "03", "missing", "05", "06", "07", "08"}
+	var last WarmProgress
+	calls := 0
+	results := model.WarmWithOptions(context.Background(), queries, WarmOptions{
+		Concurrency: 3,
+		OnResult: func(result WarmResult, progress WarmProgress) {
+			calls++
+			last = progress
+		},
+	})
+
+	if maxRunning > 3 {
+		t.Errorf("Expected at most 3 concurrent lookups, got %d", maxRunning)
+	}
+	for i, q := range queries {
+		if results[i].Query != q {
+			t.Errorf("Expected result %d for %q, got %q", i, q, results[i].Query)
+		}
+	}
+	if calls != len(queries) || last.Done != len(queries) || last.Failed != 1 || last.Total != len(queries) {
+		t.Errorf("Expected final progress %d/%d with 1 failure, got %+v after %d calls", len(queries), len(queries), last, calls)
+	}
+}
+
+// Test para WarmWithOptions: el ritmo limita las consultas por segundo
+func TestWarmWithOptions_Rate(t *testing.T) {
+	model := newCacheTestModel()
+
+	start := time.Now()
+	model.WarmWithOptions(context.Background(), []string{"star wars", "alien", "tt0076759"}, WarmOptions{
+		Concurrency: 3,
+		Rate:        50,
+	})
+	// Tres consultas a 50/s necesitan al menos tres intervalos de 20ms
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("Expected the rate limit to pace the warm-up, took %s", elapsed)
+	}
+}
+
+// Test para getCached: cada llamada recibe su propio resultado, y un acierto
+// no marca como cacheado el que recibió quien guardó la película
+func TestGetCached_ResultsAreIndependent(t *testing.T) {
+	model := newCacheTestModel()
+	ctx := context.Background()
+
+	first, err := model.GetByTitle(ctx, "alien")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := model.GetByTitle(ctx, "alien")
+	if first.FromCache || !second.FromCache {
+		t.Errorf("Expected FromCache=false then true, got %v and %v", first.FromCache, second.FromCache)
+	}
+	if first == second {
+		t.Error("Expected a different result for each call")
+	}
+
+	before := time.Now()
+	model.GetByID(ctx, "tt0078748")
+	entries := model.CacheEntries("alien")
+	if len(entries) != 1 || entries[0].Hits != 2 {
+		t.Fatalf("Expected 2 hits on Alien, got %+v", entries)
+	}
+	if entries[0].LastAccess.Before(before) || !entries[0].LastAccess.After(entries[0].CachedAt) {
+		t.Errorf("Expected last access after the second hit, got %s", entries[0].LastAccess)
+	}
+}
+
+// Test de concurrencia para ejecutar con -race: muchas lecturas mientras otras
+// goroutines guardan, eliminan, importan y listan películas
+func TestCache_ConcurrentAccess(t *testing.T) {
+	model := newCacheTestModel()
+	ctx := context.Background()
+	model.GetByTitle(ctx, "star wars")
+
+	var snapshot bytes.Buffer
+	if _, err := model.Export(&snapshot); err != nil {
+		t.Fatal(err)
+	}
+
+	const readers = 50
+	const iterations = 200
+	var wg sync.WaitGroup
+	var hits atomic.Int64
+	for i := 0; i < readers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations; j++ {
+				result, err := model.GetByTitle(ctx, "star wars")
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if result.FromCache {
+					hits.Add(1)
+				}
+				// Cada resultado es propio: modificarlo no afecta a los demás
+				result.FromCache = false
+			}
+		}()
+	}
+
+	writers := []func(){
+		func() { model.GetByTitle(ctx, "alien") },
+		func() { model.Evict("id:tt0078748") },
+		func() { model.Import(bytes.NewReader(snapshot.Bytes()), ImportOverwrite) },
+		func() { model.CacheEntries("") },
+		func() { model.Warm(ctx, []string{"alien", "tt0076759"}) },
+		func() { model.Export(io.Discard) },
+	}
+	for _, write := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < iterations/10; j++ {
+				write()
+			}
+		}()
+	}
+	wg.Wait()
+
+	cacheHits, _ := model.GetCacheStats()
+	if int64(cacheHits) < hits.Load() {
+		t.Errorf("Expected at least %d hits in the stats, got %d", hits.Load(), cacheHits)
+	}
+	if hits.Load() == 0 {
+		t.Error("Expected the readers to hit the cache")
+	}
+}
+
+// Test para las claves normalizadas: las variantes de un título y el ID llevan
+// a la misma entrada con una sola consulta a OMDB
+func TestGetByTitle_CanonicalKeys(t *testing.T) {
+	calls := 0
+	model := &MovieModel{
+		client: &MockClient{
+			GetMovieByTitleFunc: func(title string) (*omdb.Movie, error) {
+				calls++
+				return &omdb.Movie{Title: "The Matrix", ImdbID: "tt0133093"}, nil
+			},
+		},
+		cache: make(map[string]*cacheEntry),
+	}
+	ctx := context.Background()
+
+	for _, title := range []string{"the matrix", "The Matrix ", "THE  MATRIX", "Thé Matrix"} {
+		if _, err := model.GetByTitle(ctx, title); err != nil {
+			t.Fatalf("%q: %v", title, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Expected a single OMDB call, got %d", calls)
+	}
+	if result, err := model.GetByID(ctx, " TT0133093"); err != nil || !result.FromCache {
+		t.Errorf("Expected the movie by ID from the cache, got %+v, %v", result, err)
+	}
+
+	// Otro título que resuelve a la misma película se añade como alias
+	model.GetByTitle(ctx, "Matrix")
+	entries := model.CacheEntries("")
+	if len(entries) != 1 || len(entries[0].Aliases) != 2 {
+		t.Fatalf("Expected one entry with two aliases, got %+v", entries)
+	}
+
+	// Se puede eliminar por cualquiera de sus títulos
+	if !model.Evict("MATRIX") {
+		t.Fatal("Expected the movie to be evicted by its alias")
+	}
+	if len(model.cache) != 0 || len(model.aliases) != 0 {
+		t.Errorf("Expected an empty index, got %v and %v", model.cache, model.aliases)
+	}
+	if _, err := model.GetByTitle(ctx, "   "); err == nil {
+		t.Error("Expected an error for a blank title")
+	}
+}
@@ -0,0 +1,250 @@
+package models
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"sort"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+)
+
+// CacheOptions limita la memoria que ocupa la caché
+type CacheOptions struct {
+	// Client, si no es nil, es el cliente de OMDB que se usa en lugar de uno
+	// nuevo con la API key, para compartir su estado con otros componentes
+	Client omdb.OMDBClient
+
+	// MaxBytes es el tamaño máximo aproximado de la caché, medido por el JSON
+	// de cada película (0 sin límite). Al superarlo se expulsan las películas
+	// leídas hace más tiempo.
+	MaxBytes int64
+	// CompressAfter, si no es 0, permite comprimir las películas que no se han
+	// leído en ese tiempo antes de expulsar ninguna
+	CompressAfter time.Duration
+
+	// L1TTL es el tiempo que una película puede estar en memoria antes de
+	// volver a buscarla en L2 u OMDB (0 sin caducidad)
+	L1TTL time.Duration
+
+	// L2, si no es nil, es el segundo nivel de la caché: más lento que la
+	// memoria, pero más grande y persistente. Las películas que se encuentran
+	// en L2 suben a L1.
+	L2 CacheBackend
+	// L2TTL es la antigüedad máxima, desde que se obtuvo de OMDB, de una
+	// película en L2 (0 sin caducidad)
+	L2TTL time.Duration
+	// WriteBack guarda en L2 en segundo plano cada WriteBackInterval (1s por
+	// defecto) en lugar de antes de devolver la película (write-through)
+	WriteBack         bool
+	WriteBackInterval time.Duration
+
+	// Peers, si no es nil, reparte las claves entre réplicas: las que son de
+	// otra se le piden a ella y se guardan en una caché pequeña de HotCacheSize
+	// películas (128 por defecto) durante HotTTL (un minuto por defecto)
+	Peers        PeerPicker
+	HotCacheSize int
+	HotTTL       time.Duration
+}
+
+// CacheStats describe la ocupación y el uso de la caché
+type CacheStats struct {
+	L1 TierStats
+	// L2 solo cuenta si hay segundo nivel
+	L2    TierStats
+	HasL2 bool
+	// Hot cuenta las películas de otras réplicas servidas desde la caché local,
+	// y PeerLoads y PeerErrors las que se les pidieron
+	Hot        TierStats
+	PeerLoads  int
+	PeerErrors int
+	Entries    int
+	Compressed int
+	Bytes      int64
+	MaxBytes   int64
+	// Evictions cuenta las películas que salieron de la caché por motivo:
+	// admin, flush, size, expired o replaced
+	Evictions map[string]int
+}
+
+// CacheStats devuelve la ocupación de la caché y sus contadores
+func (m *MovieModel) CacheStats() CacheStats {
+	m.statsMu.Lock()
+	l1, l2, hot := m.l1Stats, m.l2Stats, m.hotStats
+	peerLoads, peerErrors := m.peerLoads, m.peerErrors
+	m.statsMu.Unlock()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return CacheStats{
+		L1:         l1,
+		L2:         l2,
+		HasL2:      m.opts.L2 != nil,
+		Hot:        hot,
+		PeerLoads:  peerLoads,
+		PeerErrors: peerErrors,
+		Entries:    m.entries,
+		Compressed: m.compressed,
+		Bytes:      m.bytes,
+		MaxBytes:   m.opts.MaxBytes,
+		Evictions:  maps.Clone(m.evictions),
+	}
+}
+
+// account suma (sign 1) o resta (sign -1) una película a la ocupación de la
+// caché. Se llama con m.mu tomado.
+func (m *MovieModel) account(e *cacheEntry, sign int) {
+	m.entries += sign
+	m.bytes += int64(sign * e.size)
+	if e.compressed != nil {
+		m.compressed += sign
+	}
+	cacheEntries.Add(float64(sign))
+	cacheBytes.Add(float64(sign * e.size))
+}
+
+// countEvictions registra n películas expulsadas por reason. Se llama con m.mu tomado.
+func (m *MovieModel) countEvictions(reason string, n int) {
+	if n == 0 {
+		return
+	}
+	if m.evictions == nil {
+		m.evictions = make(map[string]int)
+	}
+	m.evictions[reason] += n
+	cacheEvictionsTotal.Add(float64(n), reason)
+}
+
+// enforceLimit reduce la caché hasta MaxBytes: primero comprime las películas
+// frías y después expulsa las leídas hace más tiempo. La película con clave
+// keep, que se acaba de guardar, no se toca. Se llama con m.mu tomado.
+func (m *MovieModel) enforceLimit(keep string) {
+	if m.opts.MaxBytes <= 0 || m.bytes <= m.opts.MaxBytes {
+		return
+	}
+
+	type candidate struct {
+		primary    string
+		entry      *cacheEntry
+		lastAccess time.Time
+	}
+	var candidates []candidate
+	for primary := range m.aliases {
+		if e, ok := m.cache[primary]; ok && primary != keep {
+			candidates = append(candidates, candidate{primary, e, e.lastAccessed()})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].lastAccess.Before(candidates[j].lastAccess)
+	})
+
+	if m.opts.CompressAfter > 0 {
+		cold := time.Now().Add(-m.opts.CompressAfter)
+		for i, c := range candidates {
+			if m.bytes <= m.opts.MaxBytes || c.lastAccess.After(cold) {
+				break
+			}
+			if c.entry.compressed != nil {
+				continue
+			}
+			compressed, err := c.entry.compress()
+			if err != nil {
+				slog.Warn("cache compression failed", "key", c.primary, "error", err)
+				continue
+			}
+			m.store(compressed, c.primary)
+			candidates[i].entry = compressed
+		}
+	}
+
+	evicted := 0
+	for _, c := range candidates {
+		if m.bytes <= m.opts.MaxBytes {
+			break
+		}
+		m.remove(c.primary)
+		evicted++
+	}
+	m.countEvictions("size", evicted)
+}
+
+// compress devuelve una copia de la entrada con la película comprimida
+func (e *cacheEntry) compress() (*cacheEntry, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.NewEncoder(zw).Encode(e.movie); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	c := &cacheEntry{
+		compressed: buf.Bytes(),
+		title:      e.title,
+		imdbID:     e.imdbID,
+		cachedAt:   e.cachedAt,
+		storedAt:   e.storedAt,
+		size:       buf.Len(),
+	}
+	c.hits.Store(e.hits.Load())
+	c.lastAccess.Store(e.lastAccess.Load())
+	return c, nil
+}
+
+// load devuelve la película de la entrada, descomprimiéndola si hace falta
+func (e *cacheEntry) load() (*omdb.Movie, error) {
+	if e.compressed == nil {
+		return e.movie, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(e.compressed))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	var movie omdb.Movie
+	if err := json.NewDecoder(zr).Decode(&movie); err != nil {
+		return nil, err
+	}
+	return &movie, nil
+}
+
+// decompress sustituye la entrada comprimida guardada bajo key por una sin
+// comprimir. Devuelve false si no se pudo descomprimir o ya no está en la caché.
+func (m *MovieModel) decompress(ctx context.Context, key string, e *cacheEntry) (*cacheEntry, bool) {
+	movie, err := e.load()
+	if err != nil {
+		slog.WarnContext(ctx, "cache decompression failed", "key", key, "error", err)
+		return nil, false
+	}
+	d := newCacheEntry(movie, e.cachedAt)
+	d.storedAt = e.storedAt
+	d.hits.Store(e.hits.Load())
+	d.lastAccess.Store(e.lastAccess.Load())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	current, ok := m.cache[key]
+	if !ok {
+		return nil, false
+	}
+	if current != e {
+		// Otra petición ya la sustituyó
+		if current.compressed != nil {
+			return nil, false
+		}
+		return current, true
+	}
+	primary := e.primaryKey(key)
+	m.store(d, primary)
+	m.enforceLimit(primary)
+	return d, true
+}
@@ -0,0 +1,99 @@
+package models
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+)
+
+// newSizedTestModel crea un modelo con el límite indicado cuyo cliente devuelve
+// películas de tamaño parecido, con el ID derivado del título
+func newSizedTestModel(opts CacheOptions) *MovieModel {
+	return &MovieModel{
+		client: &MockClient{
+			GetMovieByTitleFunc: func(title string) (*omdb.Movie, error) {
+				return &omdb.Movie{
+					Title:  title,
+					ImdbID: "tt" + title,
+					Plot:   strings.Repeat("plot ", 100),
+				}, nil
+			},
+		},
+		cache: make(map[string]*cacheEntry),
+		opts:  opts,
+	}
+}
+
+// Test para el límite en bytes: se expulsan las películas leídas hace más tiempo
+func TestCache_MaxBytes(t *testing.T) {
+	ctx := context.Background()
+	size := int64(movieSize(&omdb.Movie{Title: "01", ImdbID: "tt01", Plot: strings.Repeat("plot ", 100)}))
+	model := newSizedTestModel(CacheOptions{MaxBytes: 3 * size})
+
+	for _, title := range []string{"01", "02", "03"} {
+		model.GetByTitle(ctx, title)
+		time.Sleep(time.Millisecond)
+	}
+	// Leer 01 la convierte en la más reciente; la siguiente en salir es 02
+	model.GetByTitle(ctx, "01")
+	model.GetByTitle(ctx, "04")
+
+	if _, ok := model.cache["id:tt02"]; ok {
+		t.Error("Expected the least recently used movie to be evicted")
+	}
+	for _, key := range []string{"id:tt01", "id:tt03", "id:tt04", "04"} {
+		if _, ok := model.cache[key]; !ok {
+			t.Errorf("Expected %s to stay cached", key)
+		}
+	}
+
+	stats := model.CacheStats()
+	if stats.Entries != 3 || stats.Bytes != 3*size || stats.MaxBytes != 3*size {
+		t.Errorf("Expected 3 entries in %d bytes, got %+v", 3*size, stats)
+	}
+	if stats.Evictions["size"] != 1 || stats.L1.Hits != 1 || stats.L1.Misses != 4 {
+		t.Errorf("Expected one eviction by size, got %+v", stats)
+	}
+
+	model.Evict("id:tt01")
+	model.Flush()
+	stats = model.CacheStats()
+	if stats.Entries != 0 || stats.Bytes != 0 || stats.Evictions["admin"] != 1 || stats.Evictions["flush"] != 2 {
+		t.Errorf("Expected an empty cache with admin and flush evictions, got %+v", stats)
+	}
+}
+
+// Test para la compresión: las películas frías se comprimen antes de expulsar
+// ninguna y se descomprimen al volver a leerlas
+func TestCache_CompressCold(t *testing.T) {
+	ctx := context.Background()
+	size := int64(movieSize(&omdb.Movie{Title: "01", ImdbID: "tt01", Plot: strings.Repeat("plot ", 100)}))
+	model := newSizedTestModel(CacheOptions{MaxBytes: 2 * size, CompressAfter: time.Nanosecond})
+
+	for _, title := range []string{"01", "02", "03"} {
+		model.GetByTitle(ctx, title)
+	}
+
+	stats := model.CacheStats()
+	if stats.Entries != 3 || stats.Compressed == 0 || len(stats.Evictions) != 0 {
+		t.Fatalf("Expected cold movies to be compressed instead of evicted, got %+v", stats)
+	}
+	if stats.Bytes > 2*size {
+		t.Errorf("Expected the cache within %d bytes, got %d", 2*size, stats.Bytes)
+	}
+
+	result, err := model.GetByTitle(ctx, "01")
+	if err != nil || !result.FromCache || result.Movie.Plot != strings.Repeat("plot ", 100) {
+		t.Fatalf("Expected the compressed movie back from the cache, got %+v, %v", result, err)
+	}
+	if model.cache["01"].compressed != nil || model.cache["01"] != model.cache["id:tt01"] {
+		t.Error("Expected the movie to be decompressed under all its keys")
+	}
+	entries := model.CacheEntries("01")
+	if len(entries) != 1 || entries[0].Hits != 1 {
+		t.Errorf("Expected the hit to be kept after decompressing, got %+v", entries)
+	}
+}
@@ -0,0 +1,35 @@
+package models
+
+import "github.com/prosales/go-api-movies/pkg/metrics"
+
+// Métricas de la caché de películas
+var (
+	cacheHitsTotal = metrics.NewCounterVec(
+		"movies_cache_hits_total",
+		"Películas servidas desde la caché por nivel.",
+		"tier",
+	)
+	cacheMissesTotal = metrics.NewCounterVec(
+		"movies_cache_misses_total",
+		"Películas que no estaban en cada nivel de la caché.",
+		"tier",
+	)
+	cacheEvictionsTotal = metrics.NewCounterVec(
+		"movies_cache_evictions_total",
+		"Entradas eliminadas de la caché por motivo.",
+		"reason",
+	)
+	cacheEntries = metrics.NewGaugeVec(
+		"movies_cache_entries",
+		"Número de películas en la caché.",
+	)
+	cachePeerLoadsTotal = metrics.NewCounterVec(
+		"movies_cache_peer_loads_total",
+		"Películas pedidas a la réplica dueña de la clave, por resultado.",
+		"result",
+	)
+	cacheBytes = metrics.NewGaugeVec(
+		"movies_cache_bytes",
+		"Tamaño aproximado de la caché en bytes.",
+	)
+)
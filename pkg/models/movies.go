@@ -1,106 +1,308 @@
 package models
 
 import (
+	"context"
 	"errors"
-	"log"
+	"io"
+	"log/slog"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/prosales/go-api-movies/pkg/omdb"
+	"github.com/prosales/go-api-movies/pkg/textnorm"
 )
 
 // MovieModelInterface define la interfaz para un modelo de películas
 type MovieModelInterface interface {
-	GetByTitle(title string) (*CachedMovie, error)
-	Search(query string) (*omdb.SearchResult, error)
+	GetByTitle(ctx context.Context, title string) (*CachedMovie, error)
+	GetByID(ctx context.Context, imdbID string) (*CachedMovie, error)
+	Search(ctx context.Context, query string) (*omdb.SearchResult, error)
 	GetCacheStats() (hits, misses int)
+
+	// Administración de la caché
+	CacheEntries(query string) []CacheEntry
+	Evict(key string) bool
+	Flush() int
+	WarmWithOptions(ctx context.Context, queries []string, opts WarmOptions) []WarmResult
+	Export(w io.Writer) (int, error)
+	Import(r io.Reader, policy ImportPolicy) (ImportStats, error)
+	CacheStats() CacheStats
 }
 
 // MovieModel representa un modelo para acceder y manipular datos de películas
 type MovieModel struct {
 	client       omdb.OMDBClient
-	cache        map[string]*CachedMovie
+	// cache es el índice de la caché: cada película está bajo su clave
+	// principal y bajo cada título con el que se pidió
+	cache        map[string]*cacheEntry
+	// aliases guarda, por clave principal, los títulos que apuntan a la película
+	aliases      map[string][]string
 	mu           sync.RWMutex
-	cacheHits    int
-	cacheMisses  int
+	// Límites, segundo nivel y ocupación de la caché, protegidos por mu
+	opts         CacheOptions
+	wb           *writeBack
+	bytes        int64
+	entries      int
+	compressed   int
+	evictions    map[string]int
+	// Los contadores de cada nivel tienen su propio bloqueo para que los
+	// aciertos solo necesiten el de lectura de la caché
+	statsMu      sync.Mutex
+	l1Stats      TierStats
+	l2Stats      TierStats
+	hotStats     TierStats
+	peerLoads    int
+	peerErrors   int
+
+	// Películas de otras réplicas, si la caché está repartida
+	hot          *hotCache
+	hotOnce      sync.Once
 }
 
-// CachedMovie representa una película con metadatos de caché
+// CachedMovie representa una película con metadatos de caché. Cada llamada
+// devuelve su propia copia; Movie la comparten todas y no se debe modificar.
 type CachedMovie struct {
 	Movie      *omdb.Movie
 	FromCache  bool
 	CachedAt   time.Time
 }
 
-// NewMovieModel crea un nuevo modelo de películas
+// cacheEntry es una película guardada en la caché. La misma entrada se guarda
+// bajo su ID y bajo cada título con el que se pidió. La película no cambia una
+// vez guardada; solo cambian los datos de acceso, que son atómicos. Las
+// entradas frías pueden guardarse comprimidas: entonces movie es nil y la
+// película está en compressed.
+type cacheEntry struct {
+	movie      *omdb.Movie
+	compressed []byte
+	title      string
+	imdbID     string
+	cachedAt   time.Time
+	// storedAt es cuándo entró en L1, de donde caduca tras L1TTL
+	storedAt   time.Time
+	size       int
+
+	hits       atomic.Int64
+	lastAccess atomic.Int64 // UnixNano del último acierto
+}
+
+// newCacheEntry crea la entrada de una película guardada en cachedAt
+func newCacheEntry(movie *omdb.Movie, cachedAt time.Time) *cacheEntry {
+	return &cacheEntry{
+		movie:    movie,
+		title:    movie.Title,
+		imdbID:   movie.ImdbID,
+		cachedAt: cachedAt,
+		storedAt: time.Now(),
+		size:     movieSize(movie),
+	}
+}
+
+// result devuelve una copia de la película para quien la pidió. La entrada no
+// debe estar comprimida.
+func (e *cacheEntry) result(fromCache bool) *CachedMovie {
+	return &CachedMovie{Movie: e.movie, FromCache: fromCache, CachedAt: e.cachedAt}
+}
+
+// touch registra un acierto
+func (e *cacheEntry) touch(now time.Time) {
+	e.hits.Add(1)
+	e.lastAccess.Store(now.UnixNano())
+}
+
+// lastAccessed devuelve cuándo se leyó la entrada por última vez, o la fecha en
+// que se guardó si no se ha leído
+func (e *cacheEntry) lastAccessed() time.Time {
+	if ns := e.lastAccess.Load(); ns != 0 {
+		return time.Unix(0, ns)
+	}
+	return e.cachedAt
+}
+
+// NewMovieModel crea un nuevo modelo de películas con una caché sin límite
 func NewMovieModel(apiKey string) *MovieModel {
-	return &MovieModel{
-		client: omdb.NewClient(apiKey),
-		cache:  make(map[string]*CachedMovie),
+	return NewMovieModelWithOptions(apiKey, CacheOptions{})
+}
+
+// NewMovieModelWithOptions crea un modelo de películas con los límites y el
+// segundo nivel de caché indicados. Si hay segundo nivel, hay que llamar a
+// Close al terminar.
+func NewMovieModelWithOptions(apiKey string, opts CacheOptions) *MovieModel {
+	m := &MovieModel{
+		client:  opts.Client,
+		cache:   make(map[string]*cacheEntry),
+		aliases: make(map[string][]string),
+		opts:    opts,
 	}
+	if m.client == nil {
+		m.client = omdb.NewClient(apiKey)
+	}
+	m.startWriteBack()
+	return m
 }
 
-// GetCacheStats devuelve estadísticas del uso de caché
+// GetCacheStats devuelve estadísticas del uso de caché: los aciertos en
+// cualquier nivel local y los fallos del último nivel
 func (m *MovieModel) GetCacheStats() (hits, misses int) {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.cacheHits, m.cacheMisses
+	m.statsMu.Lock()
+	defer m.statsMu.Unlock()
+	hits = m.l1Stats.Hits + m.l2Stats.Hits + m.hotStats.Hits
+	if m.opts.L2 == nil {
+		return hits, m.l1Stats.Misses
+	}
+	return hits, m.l2Stats.Misses
+}
+
+// Ping comprueba que la caché responde. Con la caché en memoria basta con
+// poder tomar el bloqueo de lectura sin quedarse esperando.
+func (m *MovieModel) Ping(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		m.mu.RLock()
+		m.mu.RUnlock()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
 // GetByTitle obtiene una película por su título
-func (m *MovieModel) GetByTitle(title string) (*CachedMovie, error) {
-	if title == "" {
+func (m *MovieModel) GetByTitle(ctx context.Context, title string) (*CachedMovie, error) {
+	key := titleKey(title)
+	if key == "" {
 		return nil, errors.New("título vacío")
 	}
 
-	// Primero verificamos en la caché
-	m.mu.RLock()
-	if cachedMovie, ok := m.cache[title]; ok {
-		m.cacheHits++
-		log.Printf("CACHÉ: Película encontrada en caché: %s", title)
-		
-		// Marcar como proveniente de caché
-		cachedMovie.FromCache = true
-		
-		m.mu.RUnlock()
-		return cachedMovie, nil
+	return m.getCached(ctx, key, title, true, func() (*omdb.Movie, error) {
+		return m.client.GetMovieByTitle(ctx, title)
+	})
+}
+
+// GetByID obtiene una película por su ID de IMDb
+func (m *MovieModel) GetByID(ctx context.Context, imdbID string) (*CachedMovie, error) {
+	if imdbID == "" {
+		return nil, errors.New("ID vacío")
 	}
+
+	return m.getCached(ctx, idKey(imdbID), imdbID, true, func() (*omdb.Movie, error) {
+		return m.client.GetMovieByID(ctx, imdbID)
+	})
+}
+
+// idKey es la clave de caché de una película por su ID, distinta de cualquier título
+func idKey(imdbID string) string {
+	return "id:" + strings.ToLower(strings.TrimSpace(imdbID))
+}
+
+// titleKey es la clave de caché de un título: las variantes que solo se
+// distinguen en mayúsculas, acentos o espacios comparten clave
+func titleKey(title string) string {
+	return textnorm.Key(title)
+}
+
+// getCached busca key, ya normalizada, en la caché y, si no está, obtiene la
+// película con fetch. Las películas se guardan bajo key y bajo su ID, para que
+// después se puedan encontrar por cualquiera de los dos. Con viaPeers, las
+// claves que son de otra réplica se le piden a ella con la consulta original query.
+func (m *MovieModel) getCached(ctx context.Context, key, query string, viaPeers bool, fetch func() (*omdb.Movie, error)) (*CachedMovie, error) {
+	// Primero verificamos en la caché. Las entradas no cambian, así que basta
+	// con el bloqueo de lectura.
+	m.mu.RLock()
+	entry, ok := m.cache[key]
 	m.mu.RUnlock()
+	if ok && m.opts.L1TTL > 0 && time.Since(entry.storedAt) > m.opts.L1TTL {
+		m.expire(key, entry)
+		ok = false
+	}
+	if ok && entry.compressed != nil {
+		// Una entrada comprimida vuelve a estar caliente: se descomprime y
+		// sustituye a la comprimida. Si falla, se trata como un fallo de caché.
+		entry, ok = m.decompress(ctx, key, entry)
+	}
+	if ok {
+		entry.touch(time.Now())
+		m.countTier(tierL1, true)
+		slog.DebugContext(ctx, "cache hit", "key", key, "tier", tierL1)
+		return entry.result(true), nil
+	}
+	m.countTier(tierL1, false)
+
+	// Las claves de otra réplica se le piden a ella
+	if viaPeers && m.opts.Peers != nil {
+		if peer, remote := m.opts.Peers.PickPeer(key); remote {
+			return m.getFromPeer(ctx, peer, key, query, fetch)
+		}
+	}
+
+	// Después en el segundo nivel, desde donde la película sube a L1
+	if m.opts.L2 != nil {
+		cached, err := m.l2Get(ctx, key)
+		if err == nil {
+			m.countTier(tierL2, true)
+			slog.DebugContext(ctx, "cache hit", "key", key, "tier", tierL2)
+			entry = newCacheEntry(cached.Movie, cached.CachedAt)
+			m.mu.Lock()
+			primary := entry.primaryKey(key)
+			m.store(entry, primary, key)
+			m.enforceLimit(primary)
+			m.mu.Unlock()
+			return entry.result(true), nil
+		}
+		if !errors.Is(err, ErrCacheMiss) {
+			slog.WarnContext(ctx, "cache L2 read failed", "key", key, "error", err)
+		}
+		m.countTier(tierL2, false)
+	}
 
 	// Si no está en la caché, lo buscamos en la API
-	m.mu.Lock()
-	m.cacheMisses++
-	m.mu.Unlock()
-	
-	log.Printf("API: Buscando película en API externa: %s", title)
-	movie, err := m.client.GetMovieByTitle(title)
+	slog.InfoContext(ctx, "cache miss", "key", key)
+	movie, err := fetch()
 	if err != nil {
 		return nil, err
 	}
 
-	// Creamos un objeto CachedMovie con metadatos
-	cachedMovie := &CachedMovie{
-		Movie:     movie,
-		FromCache: false,
-		CachedAt:  time.Now(),
+	// Guardamos en la caché
+	primary := key
+	if movie.ImdbID != "" {
+		primary = idKey(movie.ImdbID)
 	}
+	entry = newCacheEntry(movie, time.Now())
 
-	// Guardamos en la caché
 	m.mu.Lock()
-	m.cache[title] = cachedMovie
+	m.store(entry, primary, key)
+	m.enforceLimit(primary)
 	m.mu.Unlock()
+	m.l2Set(ctx, entry.result(false), primary, key)
+
+	return entry.result(false), nil
+}
 
-	return cachedMovie, nil
+// expire saca de L1 la película caducada guardada bajo key, si sigue ahí
+func (m *MovieModel) expire(key string, e *cacheEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cache[key] != e {
+		return
+	}
+	m.remove(e.primaryKey(key))
+	m.countEvictions("expired", 1)
 }
 
 // Search busca películas que coincidan con el término de búsqueda
-func (m *MovieModel) Search(query string) (*omdb.SearchResult, error) {
+func (m *MovieModel) Search(ctx context.Context, query string) (*omdb.SearchResult, error) {
 	if query == "" {
 		return nil, errors.New("consulta vacía")
 	}
 
-	log.Printf("API: Buscando película(s) con consulta: %s", query)
-	result, err := m.client.SearchByTitle(query)
+	slog.InfoContext(ctx, "search", "query", query)
+	result, err := m.client.SearchByTitle(ctx, query)
 	if err != nil {
 		return nil, err
 	}
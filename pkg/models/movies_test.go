@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -11,16 +12,21 @@ import (
 type MockClient struct {
 	SearchByTitleFunc   func(title string) (*omdb.SearchResult, error)
 	GetMovieByTitleFunc func(title string) (*omdb.Movie, error)
+	GetMovieByIDFunc    func(imdbID string) (*omdb.Movie, error)
 }
 
-func (m *MockClient) SearchByTitle(title string) (*omdb.SearchResult, error) {
+func (m *MockClient) SearchByTitle(ctx context.Context, title string) (*omdb.SearchResult, error) {
 	return m.SearchByTitleFunc(title)
 }
 
-func (m *MockClient) GetMovieByTitle(title string) (*omdb.Movie, error) {
+func (m *MockClient) GetMovieByTitle(ctx context.Context, title string) (*omdb.Movie, error) {
 	return m.GetMovieByTitleFunc(title)
 }
 
+func (m *MockClient) GetMovieByID(ctx context.Context, imdbID string) (*omdb.Movie, error) {
+	return m.GetMovieByIDFunc(imdbID)
+}
+
 // Test para GetByTitle cuando la película está en caché
 func TestGetByTitle_FromCache(t *testing.T) {
 	// Crear un cliente mock
@@ -34,7 +40,7 @@ func TestGetByTitle_FromCache(t *testing.T) {
 	// Crear el modelo con el cliente mock
 	model := &MovieModel{
 		client: mockClient,
-		cache:  make(map[string]*CachedMovie),
+		cache:  make(map[string]*cacheEntry),
 	}
 
 	// Preparar datos en caché
@@ -42,13 +48,10 @@ func TestGetByTitle_FromCache(t *testing.T) {
 		Title: "Cached Movie",
 		Year:  "2023",
 	}
-	model.cache["test_movie"] = &CachedMovie{
-		Movie:    testMovie,
-		CachedAt: time.Now(),
-	}
+	model.cache["test_movie"] = newCacheEntry(testMovie, time.Now())
 
 	// Realizar la búsqueda
-	result, err := model.GetByTitle("test_movie")
+	result, err := model.GetByTitle(context.Background(), "test_movie")
 	if err != nil {
 		t.Errorf("Expected no error, got %s", err)
 	}
@@ -60,8 +63,8 @@ func TestGetByTitle_FromCache(t *testing.T) {
 	if !result.FromCache {
 		t.Error("Expected FromCache=true, got false")
 	}
-	if model.cacheHits != 1 {
-		t.Errorf("Expected cacheHits=1, got %d", model.cacheHits)
+	if model.l1Stats.Hits != 1 {
+		t.Errorf("Expected l1 hits=1, got %d", model.l1Stats.Hits)
 	}
 }
 
@@ -81,11 +84,11 @@ func TestGetByTitle_FromAPI(t *testing.T) {
 	// Crear el modelo con el cliente mock
 	model := &MovieModel{
 		client: mockClient,
-		cache:  make(map[string]*CachedMovie),
+		cache:  make(map[string]*cacheEntry),
 	}
 
 	// Realizar la búsqueda
-	result, err := model.GetByTitle("new_movie")
+	result, err := model.GetByTitle(context.Background(), "new_movie")
 	if err != nil {
 		t.Errorf("Expected no error, got %s", err)
 	}
@@ -97,8 +100,8 @@ func TestGetByTitle_FromAPI(t *testing.T) {
 	if result.FromCache {
 		t.Error("Expected FromCache=false, got true")
 	}
-	if model.cacheMisses != 1 {
-		t.Errorf("Expected cacheMisses=1, got %d", model.cacheMisses)
+	if model.l1Stats.Misses != 1 {
+		t.Errorf("Expected l1 misses=1, got %d", model.l1Stats.Misses)
 	}
 
 	// Verificar que la película se guardó en caché
@@ -106,8 +109,69 @@ func TestGetByTitle_FromAPI(t *testing.T) {
 	if !exists {
 		t.Error("Expected movie to be cached, but it wasn't")
 	}
-	if cached.Movie.Title != "API Movie" {
-		t.Errorf("Expected cached Title=API Movie, got %s", cached.Movie.Title)
+	if cached.movie.Title != "API Movie" {
+		t.Errorf("Expected cached Title=API Movie, got %s", cached.movie.Title)
+	}
+}
+
+// Test para GetByID: una película obtenida por título se encuentra después por su ID
+func TestGetByID_AfterTitle(t *testing.T) {
+	mockClient := &MockClient{
+		GetMovieByTitleFunc: func(title string) (*omdb.Movie, error) {
+			return &omdb.Movie{Title: "API Movie", ImdbID: "tt7654321"}, nil
+		},
+		GetMovieByIDFunc: func(imdbID string) (*omdb.Movie, error) {
+			t.Error("No debería llamar a GetMovieByID cuando la película está en caché")
+			return nil, nil
+		},
+	}
+
+	model := &MovieModel{
+		client: mockClient,
+		cache:  make(map[string]*cacheEntry),
+	}
+
+	if _, err := model.GetByTitle(context.Background(), "api movie"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+
+	result, err := model.GetByID(context.Background(), "tt7654321")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if result.Movie.Title != "API Movie" {
+		t.Errorf("Expected Title=API Movie, got %s", result.Movie.Title)
+	}
+	if !result.FromCache {
+		t.Error("Expected FromCache=true, got false")
+	}
+}
+
+// Test para GetByID cuando la película no está en caché
+func TestGetByID_FromAPI(t *testing.T) {
+	mockClient := &MockClient{
+		GetMovieByIDFunc: func(imdbID string) (*omdb.Movie, error) {
+			if imdbID != "tt7654321" {
+				t.Errorf("Expected imdbID=tt7654321, got %s", imdbID)
+			}
+			return &omdb.Movie{Title: "API Movie", ImdbID: imdbID}, nil
+		},
+	}
+
+	model := &MovieModel{
+		client: mockClient,
+		cache:  make(map[string]*cacheEntry),
+	}
+
+	result, err := model.GetByID(context.Background(), "tt7654321")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if result.FromCache {
+		t.Error("Expected FromCache=false, got true")
+	}
+	if _, ok := model.cache["id:tt7654321"]; !ok {
+		t.Error("Expected movie to be cached by ID")
 	}
 }
 
@@ -139,11 +203,11 @@ func TestSearch(t *testing.T) {
 	// Crear el modelo con el cliente mock
 	model := &MovieModel{
 		client: mockClient,
-		cache:  make(map[string]*CachedMovie),
+		cache:  make(map[string]*cacheEntry),
 	}
 
 	// Realizar la búsqueda
-	result, err := model.Search("test_search")
+	result, err := model.Search(context.Background(), "test_search")
 	if err != nil {
 		t.Errorf("Expected no error, got %s", err)
 	}
@@ -173,11 +237,11 @@ func TestNewMovieModel(t *testing.T) {
 	if model.cache == nil {
 		t.Error("Expected cache to be initialized, got nil")
 	}
-	if model.cacheHits != 0 {
-		t.Errorf("Expected cacheHits=0, got %d", model.cacheHits)
+	if model.l1Stats.Hits != 0 {
+		t.Errorf("Expected l1 hits=0, got %d", model.l1Stats.Hits)
 	}
-	if model.cacheMisses != 0 {
-		t.Errorf("Expected cacheMisses=0, got %d", model.cacheMisses)
+	if model.l1Stats.Misses != 0 {
+		t.Errorf("Expected l1 misses=0, got %d", model.l1Stats.Misses)
 	}
 }
 
@@ -185,9 +249,8 @@ func TestNewMovieModel(t *testing.T) {
 func TestGetCacheStats(t *testing.T) {
 	model := &MovieModel{
 		client:      &MockClient{},
-		cache:       make(map[string]*CachedMovie),
-		cacheHits:   5,
-		cacheMisses: 3,
+		cache:       make(map[string]*cacheEntry),
+		l1Stats:     TierStats{Hits: 5, Misses: 3},
 	}
 
 	hits, misses := model.GetCacheStats()
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+	"github.com/prosales/go-api-movies/pkg/peers"
+)
+
+// Valores por defecto de la caché de claves de otras réplicas
+const (
+	defaultHotCacheSize = 128
+	defaultHotTTL       = time.Minute
+)
+
+// Nivel de la caché con las películas de otras réplicas, tal como aparece en las métricas
+const tierHot = "hot"
+
+// PeerPicker reparte las claves de la caché entre réplicas. Lo implementa peers.Pool.
+type PeerPicker interface {
+	// PickPeer devuelve el dueño de key y si es otra réplica
+	PickPeer(key string) (peer string, remote bool)
+	// Fetch pide key a peer, con la consulta original query
+	Fetch(ctx context.Context, peer, key, query string) ([]byte, error)
+}
+
+// peerRecord es una película tal como viaja entre réplicas
+type peerRecord struct {
+	CachedAt  time.Time   `json:"cached_at"`
+	FromCache bool        `json:"from_cache"`
+	Movie     *omdb.Movie `json:"movie"`
+}
+
+// hotCache guarda poco tiempo las películas pedidas a otras réplicas, para no
+// preguntarles por cada petición de las más populares
+type hotCache struct {
+	mu    sync.Mutex
+	size  int
+	ttl   time.Duration
+	order *list.List // Elementos *hotItem, del más reciente al más antiguo
+	items map[string]*list.Element
+}
+
+// hotItem es una película de hotCache
+type hotItem struct {
+	key     string
+	movie   *CachedMovie
+	expires time.Time
+}
+
+// newHotCache crea una caché de size películas que caducan tras ttl
+func newHotCache(size int, ttl time.Duration) *hotCache {
+	if size <= 0 {
+		size = defaultHotCacheSize
+	}
+	if ttl <= 0 {
+		ttl = defaultHotTTL
+	}
+	return &hotCache{size: size, ttl: ttl, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// get devuelve la película de key si no ha caducado
+func (h *hotCache) get(key string) (*CachedMovie, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	el, ok := h.items[key]
+	if !ok {
+		return nil, false
+	}
+	item := el.Value.(*hotItem)
+	if time.Now().After(item.expires) {
+		h.order.Remove(el)
+		delete(h.items, key)
+		return nil, false
+	}
+	h.order.MoveToFront(el)
+	return item.movie, true
+}
+
+// add guarda la película de key, expulsando la usada hace más tiempo si no cabe
+func (h *hotCache) add(key string, movie *CachedMovie) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	item := &hotItem{key: key, movie: movie, expires: time.Now().Add(h.ttl)}
+	if el, ok := h.items[key]; ok {
+		el.Value = item
+		h.order.MoveToFront(el)
+		return
+	}
+	h.items[key] = h.order.PushFront(item)
+	if h.order.Len() > h.size {
+		oldest := h.order.Back()
+		h.order.Remove(oldest)
+		delete(h.items, oldest.Value.(*hotItem).key)
+	}
+}
+
+// hotCache devuelve la caché de películas de otras réplicas, creándola la primera vez
+func (m *MovieModel) hotCache() *hotCache {
+	m.hotOnce.Do(func() {
+		m.hot = newHotCache(m.opts.HotCacheSize, m.opts.HotTTL)
+	})
+	return m.hot
+}
+
+// getFromPeer obtiene key de su dueño, peer. Si la réplica no responde, la
+// película se pide a OMDB con fetch para no fallar la petición.
+func (m *MovieModel) getFromPeer(ctx context.Context, peer, key, query string, fetch func() (*omdb.Movie, error)) (*CachedMovie, error) {
+	hot := m.hotCache()
+	if cached, ok := hot.get(key); ok {
+		m.countTier(tierHot, true)
+		slog.DebugContext(ctx, "cache hit", "key", key, "tier", tierHot)
+		return &CachedMovie{Movie: cached.Movie, FromCache: true, CachedAt: cached.CachedAt}, nil
+	}
+	m.countTier(tierHot, false)
+
+	data, err := m.opts.Peers.Fetch(ctx, peer, key, query)
+	if errors.Is(err, peers.ErrNotFound) {
+		return nil, omdb.ErrNotFound
+	}
+	var rec peerRecord
+	if err == nil {
+		err = json.Unmarshal(data, &rec)
+		if err == nil && rec.Movie == nil {
+			err = errors.New("respuesta sin película")
+		}
+	}
+	if err != nil {
+		m.statsMu.Lock()
+		m.peerErrors++
+		m.statsMu.Unlock()
+		cachePeerLoadsTotal.Inc("error")
+		slog.WarnContext(ctx, "cache peer load failed", "key", key, "peer", peer, "error", err)
+
+		movie, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		rec = peerRecord{Movie: movie, CachedAt: time.Now()}
+	} else {
+		m.statsMu.Lock()
+		m.peerLoads++
+		m.statsMu.Unlock()
+		cachePeerLoadsTotal.Inc("ok")
+		slog.InfoContext(ctx, "cache peer load", "key", key, "peer", peer)
+	}
+
+	hot.add(key, &CachedMovie{Movie: rec.Movie, CachedAt: rec.CachedAt})
+	return &CachedMovie{Movie: rec.Movie, FromCache: rec.FromCache, CachedAt: rec.CachedAt}, nil
+}
+
+// LoadForPeer atiende la petición de key de otra réplica: la busca en la caché
+// local y, si no está, en OMDB con la consulta original query, sin preguntar
+// a otras réplicas. Se usa con peers.Handler.
+func (m *MovieModel) LoadForPeer(ctx context.Context, key, query string) ([]byte, error) {
+	var cached *CachedMovie
+	var err error
+	if id, ok := strings.CutPrefix(key, "id:"); ok {
+		if !omdb.IsIMDbID(id) {
+			return nil, fmt.Errorf("clave %q no válida", key)
+		}
+		cached, err = m.getCached(ctx, key, id, false, func() (*omdb.Movie, error) {
+			return m.client.GetMovieByID(ctx, id)
+		})
+	} else {
+		if query == "" {
+			query = key
+		}
+		if titleKey(query) != key {
+			return nil, fmt.Errorf("la consulta %q no corresponde a la clave %q", query, key)
+		}
+		cached, err = m.getCached(ctx, key, query, false, func() (*omdb.Movie, error) {
+			return m.client.GetMovieByTitle(ctx, query)
+		})
+	}
+	if errors.Is(err, omdb.ErrNotFound) {
+		return nil, fmt.Errorf("%w: %w", peers.ErrNotFound, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(peerRecord{CachedAt: cached.CachedAt, FromCache: cached.FromCache, Movie: cached.Movie})
+}
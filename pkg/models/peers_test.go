@@ -0,0 +1,173 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+	"github.com/prosales/go-api-movies/pkg/peers"
+)
+
+// newPeerCluster arranca n réplicas en servidores de pruebas que comparten la
+// caché. calls cuenta las consultas a OMDB de todas ellas.
+func newPeerCluster(t *testing.T, n int) (nodes []*MovieModel, servers []*httptest.Server, calls *atomic.Int64) {
+	t.Helper()
+	calls = new(atomic.Int64)
+	client := &MockClient{
+		GetMovieByTitleFunc: func(title string) (*omdb.Movie, error) {
+			calls.Add(1)
+			if title == "missing" {
+				return nil, omdb.ErrNotFound
+			}
+			return &omdb.Movie{Title: title, ImdbID: fmt.Sprintf("tt%07d", crc32.ChecksumIEEE([]byte(title))%10000000)}, nil
+		},
+		GetMovieByIDFunc: func(imdbID string) (*omdb.Movie, error) {
+			calls.Add(1)
+			return &omdb.Movie{Title: "By ID", ImdbID: imdbID}, nil
+		},
+	}
+
+	nodes = make([]*MovieModel, n)
+	var urls []string
+	for i := 0; i < n; i++ {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peers.Handler(nodes[i].LoadForPeer, "secret").ServeHTTP(w, r)
+		}))
+		t.Cleanup(srv.Close)
+		servers = append(servers, srv)
+		urls = append(urls, srv.URL)
+	}
+	for i := range nodes {
+		nodes[i] = &MovieModel{
+			client: client,
+			cache:  make(map[string]*cacheEntry),
+			opts:   CacheOptions{Peers: peers.NewPool(urls[i], urls, "secret")},
+		}
+	}
+	return nodes, servers, calls
+}
+
+// Test de la caché repartida: cada clave se pide a OMDB una sola vez, desde su
+// dueño, aunque la pidan todas las réplicas
+func TestPeers_SharedCache(t *testing.T) {
+	nodes, _, calls := newPeerCluster(t, 3)
+	ctx := context.Background()
+
+	titles := []string{"Star Wars", "Alien", "The Matrix", "Amélie", "Heat", "Up"}
+	for _, node := range nodes {
+		for _, title := range titles {
+			result, err := node.GetByTitle(ctx, title)
+			if err != nil {
+				t.Fatalf("%s: %v", title, err)
+			}
+			if result.Movie.Title != title {
+				t.Errorf("Expected %s, got %s", title, result.Movie.Title)
+			}
+		}
+	}
+	if got := calls.Load(); got != int64(len(titles)) {
+		t.Errorf("Expected one OMDB call per title, got %d", got)
+	}
+
+	// Cada película queda solo en la caché principal de su dueño
+	owned := 0
+	for _, node := range nodes {
+		owned += len(node.CacheEntries(""))
+	}
+	if owned != len(titles) {
+		t.Errorf("Expected each movie in a single main cache, got %d entries", owned)
+	}
+
+	// Las réplicas que no son dueñas sirven las repetidas desde su caché local
+	var peerLoads, hotHits int
+	for _, node := range nodes {
+		for _, title := range titles {
+			node.GetByTitle(ctx, title)
+		}
+		stats := node.CacheStats()
+		peerLoads += stats.PeerLoads
+		hotHits += stats.Hot.Hits
+	}
+	if peerLoads != 2*len(titles) || hotHits != 2*len(titles) {
+		t.Errorf("Expected %d peer loads and hot hits, got %d and %d", 2*len(titles), peerLoads, hotHits)
+	}
+}
+
+// Test de la caché repartida: los no encontrados se propagan y una réplica
+// caída no hace fallar las peticiones
+func TestPeers_Errors(t *testing.T) {
+	nodes, servers, calls := newPeerCluster(t, 2)
+	ctx := context.Background()
+
+	for _, node := range nodes {
+		if _, err := node.GetByTitle(ctx, "missing"); !errors.Is(err, omdb.ErrNotFound) {
+			t.Errorf("Expected omdb.ErrNotFound, got %v", err)
+		}
+	}
+
+	// Con la otra réplica caída, sus claves se piden a OMDB directamente
+	servers[1].Close()
+	var remote string
+	for i := 0; i < 1000; i++ {
+		title := "movie " + strconv.Itoa(i)
+		if _, isRemote := nodes[0].opts.Peers.PickPeer(titleKey(title)); isRemote {
+			remote = title
+			break
+		}
+	}
+	if remote == "" {
+		t.Fatal("Expected some key to belong to the second node")
+	}
+	before := calls.Load()
+	if _, err := nodes[0].GetByTitle(ctx, remote); err != nil {
+		t.Fatalf("Expected a fallback to OMDB, got %v", err)
+	}
+	if calls.Load() != before+1 || nodes[0].CacheStats().PeerErrors != 1 {
+		t.Errorf("Expected one OMDB call and one peer error, got %d calls and %+v", calls.Load()-before, nodes[0].CacheStats())
+	}
+}
+
+// Test para LoadForPeer: solo acepta claves que corresponden a la consulta
+func TestLoadForPeer_InvalidKey(t *testing.T) {
+	model := newCacheTestModel()
+	ctx := context.Background()
+	if _, err := model.LoadForPeer(ctx, "id:../etc", ""); err == nil {
+		t.Error("Expected an invalid IMDb ID to be rejected")
+	}
+	if _, err := model.LoadForPeer(ctx, "alien", "Star Wars"); err == nil {
+		t.Error("Expected a query that does not match the key to be rejected")
+	}
+	if _, err := model.LoadForPeer(ctx, "alien", ""); err != nil {
+		t.Errorf("Expected the key to be used as query, got %v", err)
+	}
+}
+
+// Test para hotCache: expulsa la menos usada y descarta las caducadas
+func TestHotCache(t *testing.T) {
+	hot := newHotCache(2, time.Hour)
+	hot.add("a", &CachedMovie{})
+	hot.add("b", &CachedMovie{})
+	hot.get("a")
+	hot.add("c", &CachedMovie{})
+	if _, ok := hot.get("b"); ok {
+		t.Error("Expected the least recently used key to be evicted")
+	}
+	if _, ok := hot.get("a"); !ok {
+		t.Error("Expected a recently used key to stay")
+	}
+
+	expired := newHotCache(2, time.Nanosecond)
+	expired.add("a", &CachedMovie{})
+	time.Sleep(time.Millisecond)
+	if _, ok := expired.get("a"); ok {
+		t.Error("Expected an expired key to be dropped")
+	}
+}
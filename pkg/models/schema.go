@@ -0,0 +1,99 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Versión actual del esquema de las películas guardadas fuera de la memoria,
+// en la caché en disco y en las instantáneas. Si cambia omdb.Movie o el
+// formato de los registros, se sube la versión y se añade la migración desde
+// la anterior a migrations.
+//
+// Historial:
+//   - 1: registros sin el campo schema, con las claves tal como se guardaron
+//   - 2: campo schema y claves normalizadas con idKey y titleKey
+const schemaVersion = 2
+
+// ErrSchemaTooNew indica que el registro lo escribió una versión más reciente
+// de la aplicación, que esta no sabe leer
+var ErrSchemaTooNew = errors.New("versión de esquema más reciente que la soportada")
+
+// migration convierte un registro, decodificado como objeto JSON genérico, de
+// su versión a la siguiente
+type migration func(rec map[string]any) error
+
+// migrations contiene la migración desde cada versión a la siguiente
+var migrations = map[int]migration{
+	1: migrateV1,
+}
+
+// migrateV1 normaliza las claves, que antes de la versión 2 podían estar
+// guardadas con mayúsculas, espacios o acentos
+func migrateV1(rec map[string]any) error {
+	key, ok := rec["key"].(string)
+	if !ok || key == "" {
+		return errors.New("falta key")
+	}
+	if id, ok := strings.CutPrefix(key, "id:"); ok {
+		rec["key"] = idKey(id)
+	} else {
+		rec["key"] = titleKey(key)
+	}
+
+	if aliases, ok := rec["aliases"].([]any); ok {
+		normalized := make([]any, 0, len(aliases))
+		for _, alias := range aliases {
+			s, ok := alias.(string)
+			if !ok {
+				return fmt.Errorf("alias %v no válido", alias)
+			}
+			normalized = append(normalized, titleKey(s))
+		}
+		rec["aliases"] = normalized
+	}
+	return nil
+}
+
+// upgradeRecord lee un registro guardado con el esquema version y lo convierte
+// al actual, decodificándolo en v. Un version 0 indica que se tome del campo
+// schema del registro. Devuelve si hizo falta alguna migración.
+func upgradeRecord(data []byte, version int, v any) (migrated bool, err error) {
+	var rec map[string]any
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return false, err
+	}
+	if version == 0 {
+		version = 1
+		if s, ok := rec["schema"].(float64); ok {
+			version = int(s)
+		}
+	}
+	if version > schemaVersion {
+		return false, fmt.Errorf("%w: %d (se soporta hasta %d)", ErrSchemaTooNew, version, schemaVersion)
+	}
+	if version < 1 {
+		return false, fmt.Errorf("versión de esquema %d no válida", version)
+	}
+
+	for ; version < schemaVersion; version++ {
+		migrate, ok := migrations[version]
+		if !ok {
+			return false, fmt.Errorf("no hay migración desde la versión %d", version)
+		}
+		if err := migrate(rec); err != nil {
+			return false, fmt.Errorf("migración desde la versión %d: %w", version, err)
+		}
+		migrated = true
+	}
+	rec["schema"] = schemaVersion
+
+	if migrated {
+		if data, err = json.Marshal(rec); err != nil {
+			return false, err
+		}
+	}
+	return migrated, json.Unmarshal(data, v)
+}
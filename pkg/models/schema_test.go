@@ -0,0 +1,98 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Test para upgradeRecord: migra los registros antiguos y rechaza los más recientes
+func TestUpgradeRecord(t *testing.T) {
+	var rec snapshotRecord
+	migrated, err := upgradeRecord([]byte(`{"key":"Star  Wars","aliases":["LA GUERRA de las galaxias"],"movie":{"Title":"Star Wars"}}`), 0, &rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !migrated || rec.Key != "star wars" || len(rec.Aliases) != 1 || rec.Aliases[0] != "la guerra de las galaxias" {
+		t.Errorf("Expected a v1 record with normalized keys, got %v %+v", migrated, rec)
+	}
+
+	var id diskRecord
+	if _, err := upgradeRecord([]byte(`{"key":"id:TT0076759 ","movie":{}}`), 1, &id); err != nil || id.Key != "id:tt0076759" || id.Schema != schemaVersion {
+		t.Errorf("Expected a normalized ID key with the current schema, got %+v, %v", id, err)
+	}
+
+	migrated, err = upgradeRecord([]byte(`{"schema":2,"key":"Kept As Is","movie":{}}`), 0, &rec)
+	if err != nil || migrated || rec.Key != "Kept As Is" {
+		t.Errorf("Expected a current record to be read unchanged, got %v %+v, %v", migrated, rec, err)
+	}
+
+	if _, err := upgradeRecord([]byte(`{"schema":99,"key":"x"}`), 0, &rec); !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("Expected ErrSchemaTooNew, got %v", err)
+	}
+	if _, err := upgradeRecord([]byte(`{"movie":{}}`), 1, &rec); err == nil {
+		t.Error("Expected a v1 record without key to fail")
+	}
+}
+
+// Test para DiskBackend: migración al leer, cuarentena y migración completa
+func TestDiskBackend_Schema(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	disk, err := NewDiskBackend(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	// Un registro de la versión 1 se lee y se reescribe con el esquema actual
+	path := write(filepath.Base(disk.path("alien")), `{"key":"alien","cached_at":"2024-01-01T00:00:00Z","movie":{"Title":"Alien"}}`)
+	if movie, err := disk.Get(ctx, "alien"); err != nil || movie.Movie.Title != "Alien" {
+		t.Fatalf("Expected the v1 record to be read, got %+v, %v", movie, err)
+	}
+	var rec diskRecord
+	data, _ := os.ReadFile(path)
+	if json.Unmarshal(data, &rec); rec.Schema != schemaVersion {
+		t.Errorf("Expected the record to be rewritten with schema %d, got %d", schemaVersion, rec.Schema)
+	}
+
+	// Un registro ilegible se aparta en lugar de fallar en cada lectura
+	write(filepath.Base(disk.path("broken")), `{"key":`)
+	if _, err := disk.Get(ctx, "broken"); err == nil || errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected a read error, got %v", err)
+	}
+	if _, err := disk.Get(ctx, "broken"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss after quarantine, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, quarantineDir, filepath.Base(disk.path("broken")))); err != nil {
+		t.Errorf("Expected the record in quarantine, got %v", err)
+	}
+
+	// Migración completa: la clave antigua cambia y con ella el archivo
+	old := write(filepath.Base(disk.path("Star Wars")), `{"key":"Star Wars","cached_at":"2024-01-01T00:00:00Z","movie":{"Title":"Star Wars"}}`)
+	write("garbage.json", `not json`)
+	write("newer.json", `{"schema":99,"key":"x"}`)
+	stats, err := disk.Migrate(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := MigrateStats{Current: 1, Migrated: 1, Newer: 1, Quarantined: 1}
+	if stats != expected {
+		t.Errorf("Expected %+v, got %+v", expected, stats)
+	}
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("Expected the old file to be removed, got %v", err)
+	}
+	if movie, err := disk.Get(ctx, "star wars"); err != nil || movie.Movie.Title != "Star Wars" {
+		t.Errorf("Expected the migrated record under its normalized key, got %+v, %v", movie, err)
+	}
+}
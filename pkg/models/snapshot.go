@@ -0,0 +1,210 @@
+package models
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+)
+
+// Formato de las instantáneas de la caché: JSON lines con una cabecera y una
+// línea por película. La versión de la cabecera es la del esquema de las
+// líneas, que se migran al importarlas.
+const (
+	snapshotFormat = "movies-cache"
+
+	// Tamaño máximo de una línea de la instantánea
+	maxSnapshotLine = 1 << 20
+)
+
+// ImportPolicy decide qué hacer al importar una película que ya está en la caché
+type ImportPolicy string
+
+const (
+	// ImportSkip conserva la película de la caché
+	ImportSkip ImportPolicy = "skip"
+	// ImportOverwrite la sustituye por la importada
+	ImportOverwrite ImportPolicy = "overwrite"
+	// ImportNewer se queda con la que se guardó más tarde
+	ImportNewer ImportPolicy = "newer"
+)
+
+// ParseImportPolicy convierte el nombre de una política de importación
+func ParseImportPolicy(s string) (ImportPolicy, error) {
+	switch p := ImportPolicy(s); p {
+	case ImportSkip, ImportOverwrite, ImportNewer:
+		return p, nil
+	}
+	return "", fmt.Errorf("política de importación %q no válida (skip, overwrite, newer)", s)
+}
+
+// ImportStats resume el resultado de una importación
+type ImportStats struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// snapshotHeader es la primera línea de una instantánea
+type snapshotHeader struct {
+	Format     string    `json:"format"`
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+}
+
+// snapshotRecord es una película de la instantánea
+type snapshotRecord struct {
+	Key      string      `json:"key"`
+	Aliases  []string    `json:"aliases,omitempty"`
+	CachedAt time.Time   `json:"cached_at"`
+	Movie    *omdb.Movie `json:"movie"`
+}
+
+// Export escribe el contenido de la caché como instantánea y devuelve cuántas
+// películas exportó
+func (m *MovieModel) Export(w io.Writer) (int, error) {
+	m.mu.RLock()
+	records := make(map[string]*snapshotRecord)
+	for key, e := range m.cache {
+		primary := e.primaryKey(key)
+		rec, ok := records[primary]
+		if !ok {
+			p := m.cache[primary]
+			if p == nil {
+				p = e
+			}
+			movie, err := p.load()
+			if err != nil {
+				m.mu.RUnlock()
+				return 0, fmt.Errorf("%s: %w", primary, err)
+			}
+			rec = &snapshotRecord{Key: primary, CachedAt: p.cachedAt, Movie: movie}
+			records[primary] = rec
+		}
+		if key != primary {
+			rec.Aliases = append(rec.Aliases, key)
+		}
+	}
+	m.mu.RUnlock()
+
+	keys := make([]string, 0, len(records))
+	for key := range records {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	if err := enc.Encode(snapshotHeader{
+		Format:     snapshotFormat,
+		Version:    schemaVersion,
+		ExportedAt: time.Now().UTC(),
+	}); err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		rec := records[key]
+		sort.Strings(rec.Aliases)
+		if err := enc.Encode(rec); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), bw.Flush()
+}
+
+// Import carga en la caché las películas de una instantánea, conservando la
+// fecha en que se guardaron. La instantánea se lee y valida entera antes de
+// modificar la caché, así que un error no deja una importación a medias.
+func (m *MovieModel) Import(r io.Reader, policy ImportPolicy) (ImportStats, error) {
+	if _, err := ParseImportPolicy(string(policy)); err != nil {
+		return ImportStats{}, err
+	}
+	records, err := readSnapshot(r)
+	if err != nil {
+		return ImportStats{}, err
+	}
+
+	// Las escrituras en L2 se hacen al final, fuera del bloqueo, para no
+	// detener la caché mientras se escribe una instantánea grande en disco
+	type l2Write struct {
+		movie *CachedMovie
+		keys  []string
+	}
+	var (
+		stats  ImportStats
+		writes []l2Write
+	)
+	m.mu.Lock()
+	for _, rec := range records {
+		entry := newCacheEntry(rec.Movie, rec.CachedAt)
+		primary := entry.primaryKey(rec.Key)
+
+		if existing, ok := m.cache[primary]; ok {
+			replace := policy == ImportOverwrite ||
+				policy == ImportNewer && rec.CachedAt.After(existing.cachedAt)
+			if !replace {
+				stats.Skipped++
+				continue
+			}
+		}
+
+		aliases := make([]string, 0, len(rec.Aliases))
+		for _, alias := range rec.Aliases {
+			aliases = append(aliases, titleKey(alias))
+		}
+		m.store(entry, primary, aliases...)
+		m.enforceLimit(primary)
+		writes = append(writes, l2Write{entry.result(false), append([]string{primary}, aliases...)})
+		stats.Imported++
+	}
+	m.mu.Unlock()
+
+	for _, w := range writes {
+		m.l2Set(context.Background(), w.movie, w.keys...)
+	}
+	return stats, nil
+}
+
+// readSnapshot lee y valida una instantánea completa
+func readSnapshot(r io.Reader) ([]snapshotRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxSnapshotLine)
+
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("instantánea vacía")
+	}
+	var header snapshotHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Format != snapshotFormat {
+		return nil, errors.New("no es una instantánea de la caché de películas")
+	}
+	if header.Version < 1 || header.Version > schemaVersion {
+		return nil, fmt.Errorf("versión de instantánea %d no soportada (se espera hasta %d)", header.Version, schemaVersion)
+	}
+
+	var records []snapshotRecord
+	for line := 2; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var rec snapshotRecord
+		if _, err := upgradeRecord(scanner.Bytes(), header.Version, &rec); err != nil {
+			return nil, fmt.Errorf("línea %d: %w", line, err)
+		}
+		if rec.Movie == nil || rec.Key == "" || rec.CachedAt.IsZero() {
+			return nil, fmt.Errorf("línea %d: faltan key, cached_at o movie", line)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+)
+
+// Test para Export e Import: la instantánea conserva las películas, sus alias
+// y la fecha en que se guardaron
+func TestExportImport_RoundTrip(t *testing.T) {
+	source := newCacheTestModel()
+	source.GetByTitle(context.Background(), "star wars")
+	source.GetByTitle(context.Background(), "alien")
+	cachedAt := source.cache["id:tt0076759"].cachedAt
+
+	var buf bytes.Buffer
+	n, err := source.Export(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("Expected 2 exported movies, got %d", n)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Errorf("Expected header and 2 lines, got %d lines", lines)
+	}
+
+	target := &MovieModel{
+		client: &MockClient{
+			GetMovieByTitleFunc: func(title string) (*omdb.Movie, error) {
+				t.Error("Imported movies should be served from the cache")
+				return nil, nil
+			},
+		},
+		cache: make(map[string]*cacheEntry),
+	}
+	stats, err := target.Import(&buf, ImportSkip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Imported != 2 || stats.Skipped != 0 {
+		t.Errorf("Expected 2 imported movies, got %+v", stats)
+	}
+
+	result, err := target.GetByTitle(context.Background(), "star wars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.FromCache || result.Movie.Title != "Star Wars" {
+		t.Errorf("Expected Star Wars from the cache by its alias, got %+v", result)
+	}
+	if !result.CachedAt.Equal(cachedAt) {
+		t.Errorf("Expected CachedAt %s to be kept, got %s", cachedAt, result.CachedAt)
+	}
+}
+
+// Test para Import con cada política de conflicto
+func TestImport_Policies(t *testing.T) {
+	old := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := old.Add(24 * time.Hour)
+	snapshot := func(cachedAt time.Time, title string) string {
+		return `{"format":"movies-cache","version":1}` + "\n" +
+			`{"key":"id:tt0076759","aliases":["star wars"],"cached_at":"` + cachedAt.Format(time.RFC3339) + `","movie":{"Title":"` + title + `","imdbID":"tt0076759"}}` + "\n"
+	}
+
+	tests := []struct {
+		policy   ImportPolicy
+		cachedAt time.Time
+		expected string
+	}{
+		{ImportSkip, newer, "Cached"},
+		{ImportOverwrite, old, "Imported"},
+		{ImportNewer, old, "Cached"},
+		{ImportNewer, newer, "Imported"},
+	}
+
+	for _, tt := range tests {
+		model := &MovieModel{client: &MockClient{}, cache: make(map[string]*cacheEntry)}
+		entry := newCacheEntry(&omdb.Movie{Title: "Cached", ImdbID: "tt0076759"}, old.Add(time.Hour))
+		model.store(entry, "id:tt0076759", "la guerra de las galaxias")
+
+		if _, err := model.Import(strings.NewReader(snapshot(tt.cachedAt, "Imported")), tt.policy); err != nil {
+			t.Fatalf("%s: %v", tt.policy, err)
+		}
+		if got := model.cache["id:tt0076759"].movie.Title; got != tt.expected {
+			t.Errorf("%s with cached_at %s: expected %s, got %s", tt.policy, tt.cachedAt, tt.expected, got)
+		}
+		// Los alias existentes siguen apuntando a la entrada vigente
+		if model.cache["la guerra de las galaxias"] != model.cache["id:tt0076759"] {
+			t.Errorf("%s: expected existing aliases to follow the entry", tt.policy)
+		}
+	}
+}
+
+// Test para Import con instantáneas no válidas: la caché no se modifica
+func TestImport_Invalid(t *testing.T) {
+	valid := `{"key":"id:tt0076759","cached_at":"2024-01-01T00:00:00Z","movie":{"Title":"Star Wars","imdbID":"tt0076759"}}`
+	tests := []struct {
+		name     string
+		snapshot string
+	}{
+		{"empty", ""},
+		{"not a snapshot", `{"Title":"Star Wars"}`},
+		{"unsupported version", `{"format":"movies-cache","version":99}`},
+		{"broken line", `{"format":"movies-cache","version":1}` + "\n" + valid + "\n{"},
+		{"missing movie", `{"format":"movies-cache","version":1}` + "\n" + `{"key":"x","cached_at":"2024-01-01T00:00:00Z"}`},
+	}
+
+	for _, tt := range tests {
+		model := &MovieModel{client: &MockClient{}, cache: make(map[string]*cacheEntry)}
+		if _, err := model.Import(strings.NewReader(tt.snapshot), ImportOverwrite); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+		if len(model.cache) != 0 {
+			t.Errorf("%s: expected the cache to be left untouched", tt.name)
+		}
+	}
+
+	if _, err := ParseImportPolicy("merge"); err == nil {
+		t.Error("Expected an error for an unknown policy")
+	}
+}
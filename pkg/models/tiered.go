@@ -0,0 +1,217 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Intervalo por defecto entre volcados a L2 en modo write-back
+const defaultWriteBackInterval = time.Second
+
+// Niveles de la caché, tal como aparecen en las métricas
+const (
+	tierL1 = "l1"
+	tierL2 = "l2"
+)
+
+// TierStats cuenta los aciertos y fallos de un nivel de la caché
+type TierStats struct {
+	Hits   int
+	Misses int
+}
+
+// writeBack acumula las escrituras a L2 pendientes de volcar. Un valor nil
+// indica que la clave se debe borrar.
+type writeBack struct {
+	mu       sync.Mutex
+	pending  map[string]*CachedMovie
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// startWriteBack arranca el volcado periódico a L2 si el modelo lo usa
+func (m *MovieModel) startWriteBack() {
+	if m.opts.L2 == nil || !m.opts.WriteBack {
+		return
+	}
+	interval := m.opts.WriteBackInterval
+	if interval <= 0 {
+		interval = defaultWriteBackInterval
+	}
+
+	m.wb = &writeBack{
+		pending: make(map[string]*CachedMovie),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go func() {
+		defer close(m.wb.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.flushWriteBack(context.Background())
+			case <-m.wb.stop:
+				return
+			}
+		}
+	}()
+}
+
+// flushWriteBack vuelca a L2 las escrituras pendientes
+func (m *MovieModel) flushWriteBack(ctx context.Context) error {
+	m.wb.mu.Lock()
+	pending := m.wb.pending
+	m.wb.pending = make(map[string]*CachedMovie)
+	m.wb.mu.Unlock()
+
+	var errs []error
+	for key, movie := range pending {
+		var err error
+		if movie == nil {
+			err = m.opts.L2.Delete(ctx, key)
+		} else {
+			err = m.opts.L2.Set(ctx, key, movie)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		slog.Warn("cache write-back failed", "keys", len(pending), "failed", len(errs), "error", err)
+		return err
+	}
+	return nil
+}
+
+// Close vuelca las escrituras pendientes a L2 y lo cierra si hace falta. Se
+// puede llamar más de una vez.
+func (m *MovieModel) Close() error {
+	if m.opts.L2 == nil {
+		return nil
+	}
+	var errs []error
+	if m.wb != nil {
+		m.wb.stopOnce.Do(func() { close(m.wb.stop) })
+		<-m.wb.done
+		errs = append(errs, m.flushWriteBack(context.Background()))
+	}
+	if c, ok := m.opts.L2.(io.Closer); ok {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// l2Get busca key en L2, teniendo en cuenta las escrituras pendientes y la
+// caducidad L2TTL, que se mide desde que la película se obtuvo de OMDB
+func (m *MovieModel) l2Get(ctx context.Context, key string) (*CachedMovie, error) {
+	if m.wb != nil {
+		m.wb.mu.Lock()
+		movie, ok := m.wb.pending[key]
+		m.wb.mu.Unlock()
+		if ok {
+			if movie == nil {
+				return nil, ErrCacheMiss
+			}
+			return movie, nil
+		}
+	}
+
+	movie, err := m.opts.L2.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if m.opts.L2TTL > 0 && time.Since(movie.CachedAt) > m.opts.L2TTL {
+		m.l2Delete(ctx, key)
+		return nil, ErrCacheMiss
+	}
+	return movie, nil
+}
+
+// l2Set guarda la película en L2 bajo cada una de las claves: en el momento
+// (write-through) o en el siguiente volcado (write-back). Los errores solo se
+// registran, porque la película ya está en L1.
+func (m *MovieModel) l2Set(ctx context.Context, movie *CachedMovie, keys ...string) {
+	if m.opts.L2 == nil {
+		return
+	}
+	stored := &CachedMovie{Movie: movie.Movie, CachedAt: movie.CachedAt}
+	if m.wb != nil {
+		m.wb.mu.Lock()
+		for _, key := range keys {
+			m.wb.pending[key] = stored
+		}
+		m.wb.mu.Unlock()
+		return
+	}
+	for _, key := range keys {
+		if err := m.opts.L2.Set(ctx, key, stored); err != nil {
+			slog.WarnContext(ctx, "cache L2 write failed", "key", key, "error", err)
+		}
+	}
+}
+
+// l2Delete elimina las claves de L2
+func (m *MovieModel) l2Delete(ctx context.Context, keys ...string) {
+	if m.opts.L2 == nil {
+		return
+	}
+	if m.wb != nil {
+		m.wb.mu.Lock()
+		for _, key := range keys {
+			m.wb.pending[key] = nil
+		}
+		m.wb.mu.Unlock()
+		return
+	}
+	for _, key := range keys {
+		if err := m.opts.L2.Delete(ctx, key); err != nil {
+			slog.WarnContext(ctx, "cache L2 delete failed", "key", key, "error", err)
+		}
+	}
+}
+
+// l2Clear vacía L2, descartando las escrituras pendientes
+func (m *MovieModel) l2Clear(ctx context.Context) {
+	if m.opts.L2 == nil {
+		return
+	}
+	if m.wb != nil {
+		m.wb.mu.Lock()
+		m.wb.pending = make(map[string]*CachedMovie)
+		m.wb.mu.Unlock()
+	}
+	if err := m.opts.L2.Clear(ctx); err != nil {
+		slog.WarnContext(ctx, "cache L2 clear failed", "error", err)
+	}
+}
+
+// countTier registra un acierto o un fallo en el nivel tier
+func (m *MovieModel) countTier(tier string, hit bool) {
+	m.statsMu.Lock()
+	stats := &m.l1Stats
+	switch tier {
+	case tierL2:
+		stats = &m.l2Stats
+	case tierHot:
+		stats = &m.hotStats
+	}
+	if hit {
+		stats.Hits++
+	} else {
+		stats.Misses++
+	}
+	m.statsMu.Unlock()
+
+	if hit {
+		cacheHitsTotal.Inc(tier)
+	} else {
+		cacheMissesTotal.Inc(tier)
+	}
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/omdb"
+)
+
+// newTieredTestModel crea un modelo con L2 en disco que cuenta las consultas a OMDB
+func newTieredTestModel(t *testing.T, l2 CacheBackend, opts CacheOptions) (*MovieModel, *int) {
+	t.Helper()
+	calls := 0
+	opts.L2 = l2
+	model := &MovieModel{
+		client: &MockClient{
+			GetMovieByTitleFunc: func(title string) (*omdb.Movie, error) {
+				calls++
+				return &omdb.Movie{Title: "Star Wars", ImdbID: "tt0076759"}, nil
+			},
+			GetMovieByIDFunc: func(imdbID string) (*omdb.Movie, error) {
+				calls++
+				return &omdb.Movie{Title: "Star Wars", ImdbID: imdbID}, nil
+			},
+		},
+		cache: make(map[string]*cacheEntry),
+		opts:  opts,
+	}
+	model.startWriteBack()
+	t.Cleanup(func() { model.Close() })
+	return model, &calls
+}
+
+// Test para DiskBackend: guardar, leer, borrar y vaciar
+func TestDiskBackend(t *testing.T) {
+	ctx := context.Background()
+	disk, err := NewDiskBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := disk.Get(ctx, "star wars"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected ErrCacheMiss, got %v", err)
+	}
+	cachedAt := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	movie := &CachedMovie{Movie: &omdb.Movie{Title: "Star Wars"}, CachedAt: cachedAt}
+	if err := disk.Set(ctx, "star wars", movie); err != nil {
+		t.Fatal(err)
+	}
+	got, err := disk.Get(ctx, "star wars")
+	if err != nil || got.Movie.Title != "Star Wars" || !got.CachedAt.Equal(cachedAt) {
+		t.Fatalf("Expected the stored movie, got %+v, %v", got, err)
+	}
+
+	if err := disk.Delete(ctx, "star wars"); err != nil {
+		t.Fatal(err)
+	}
+	if err := disk.Delete(ctx, "star wars"); err != nil {
+		t.Errorf("Expected deleting a missing key to succeed, got %v", err)
+	}
+	disk.Set(ctx, "alien", movie)
+	if err := disk.Clear(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := disk.Get(ctx, "alien"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected an empty backend after Clear, got %v", err)
+	}
+}
+
+// Test para la caché en dos niveles con write-through: lo guardado en L2
+// sobrevive a un modelo nuevo y sube a L1 al leerlo
+func TestTiered_WriteThroughAndPromotion(t *testing.T) {
+	ctx := context.Background()
+	disk, _ := NewDiskBackend(t.TempDir())
+
+	first, calls := newTieredTestModel(t, disk, CacheOptions{})
+	first.GetByTitle(ctx, "Star Wars")
+	if *calls != 1 {
+		t.Fatalf("Expected one OMDB call, got %d", *calls)
+	}
+
+	// Un modelo nuevo, con L1 vacío, encuentra la película en disco
+	second, calls := newTieredTestModel(t, disk, CacheOptions{})
+	result, err := second.GetByID(ctx, "tt0076759")
+	if err != nil || !result.FromCache {
+		t.Fatalf("Expected the movie from L2, got %+v, %v", result, err)
+	}
+	second.GetByTitle(ctx, "star wars")
+	second.GetByID(ctx, "tt0076759")
+	if *calls != 0 {
+		t.Errorf("Expected no OMDB calls, got %d", *calls)
+	}
+
+	stats := second.CacheStats()
+	if stats.L2 != (TierStats{Hits: 2}) || stats.L1 != (TierStats{Hits: 1, Misses: 2}) || !stats.HasL2 {
+		t.Errorf("Expected L1 1/2 and L2 2/0, got L1 %+v and L2 %+v", stats.L1, stats.L2)
+	}
+	if hits, misses := second.GetCacheStats(); hits != 3 || misses != 0 {
+		t.Errorf("Expected 3 hits and no misses overall, got %d/%d", hits, misses)
+	}
+
+	// Eliminar desde la administración también lo borra de L2
+	second.Evict("id:tt0076759")
+	if _, err := disk.Get(ctx, "star wars"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected the evicted movie to be removed from L2, got %v", err)
+	}
+}
+
+// Test para las caducidades: L1TTL manda a buscar en L2 y L2TTL a OMDB
+func TestTiered_TTL(t *testing.T) {
+	ctx := context.Background()
+	disk, _ := NewDiskBackend(t.TempDir())
+	model, calls := newTieredTestModel(t, disk, CacheOptions{L1TTL: time.Nanosecond})
+
+	model.GetByTitle(ctx, "star wars")
+	result, _ := model.GetByTitle(ctx, "star wars")
+	if !result.FromCache || *calls != 1 {
+		t.Errorf("Expected the expired L1 entry to be served from L2, got FromCache=%v after %d calls", result.FromCache, *calls)
+	}
+	if stats := model.CacheStats(); stats.Evictions["expired"] == 0 || stats.L2.Hits != 1 {
+		t.Errorf("Expected an expiry and an L2 hit, got %+v", stats)
+	}
+
+	// Una película de L2 más antigua que L2TTL se vuelve a pedir
+	old := &CachedMovie{Movie: &omdb.Movie{Title: "Alien", ImdbID: "tt0078748"}, CachedAt: time.Now().Add(-2 * time.Hour)}
+	disk.Set(ctx, "id:tt0078748", old)
+	model, calls = newTieredTestModel(t, disk, CacheOptions{L2TTL: time.Hour})
+	if result, _ := model.GetByID(ctx, "tt0078748"); result.FromCache || *calls != 1 {
+		t.Errorf("Expected the stale L2 entry to be fetched again, got FromCache=%v after %d calls", result.FromCache, *calls)
+	}
+}
+
+// Test para write-back: las escrituras se ven enseguida, pero llegan a L2 al volcar
+func TestTiered_WriteBack(t *testing.T) {
+	ctx := context.Background()
+	disk, _ := NewDiskBackend(t.TempDir())
+	model, calls := newTieredTestModel(t, disk, CacheOptions{WriteBack: true, WriteBackInterval: time.Hour})
+
+	model.GetByTitle(ctx, "star wars")
+	if _, err := disk.Get(ctx, "star wars"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Expected the write to be pending, got %v", err)
+	}
+
+	// L1 vacío: la película sale de las escrituras pendientes
+	model.Flush()
+	model.GetByTitle(ctx, "star wars")
+	if *calls != 2 {
+		t.Errorf("Expected Flush to discard pending writes, got %d OMDB calls", *calls)
+	}
+	model.mu.Lock()
+	model.remove("id:tt0076759")
+	model.mu.Unlock()
+	if result, _ := model.GetByTitle(ctx, "star wars"); !result.FromCache || *calls != 2 {
+		t.Errorf("Expected the pending write to be read back, got FromCache=%v after %d calls", result.FromCache, *calls)
+	}
+
+	if err := model.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := disk.Get(ctx, "id:tt0076759"); err != nil {
+		t.Errorf("Expected Close to write the pending movies, got %v", err)
+	}
+}
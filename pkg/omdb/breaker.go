@@ -0,0 +1,90 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Límites del cortocircuito: tras breakerThreshold fallos seguidos no se llama
+// a OMDB durante breakerCooldown
+const (
+	breakerThreshold = 5
+	breakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen indica que OMDB está fallando y no se le hacen peticiones
+var ErrCircuitOpen = errors.New("circuito de OMDB abierto")
+
+// breaker cuenta los fallos seguidos de OMDB. Pasado el tiempo de espera queda
+// medio abierto: deja pasar una sola petición de prueba, que lo cierra si acierta
+// y lo vuelve a abrir si falla. Su valor cero está cerrado.
+type breaker struct {
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow devuelve ErrCircuitOpen si el circuito está abierto o si ya hay una
+// petición de prueba en curso. probe indica que la petición es la de prueba.
+func (b *breaker) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err := b.check(); err != nil {
+		return false, err
+	}
+	if b.failures >= breakerThreshold {
+		b.probing = true
+		return true, nil
+	}
+	return false, nil
+}
+
+// check devuelve ErrCircuitOpen si no se dejaría pasar ninguna petición, sin
+// reservar la de prueba. Debe llamarse con b.mu bloqueado.
+func (b *breaker) check() error {
+	if wait := time.Until(b.openUntil); wait > 0 {
+		return fmt.Errorf("%w durante %s", ErrCircuitOpen, wait.Round(time.Second))
+	}
+	if b.probing {
+		return fmt.Errorf("%w: hay una petición de prueba en curso", ErrCircuitOpen)
+	}
+	return nil
+}
+
+// record registra el resultado de una petición; probe es el valor que devolvió
+// allow. Que la película no exista o que el cliente cancele la petición no son
+// fallos de OMDB. Con el circuito abierto solo cuenta la petición de prueba: las
+// que empezaron antes de abrirlo no alargan la espera.
+func (b *breaker) record(err error, probe bool) {
+	failed := err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, context.Canceled)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if probe {
+		b.probing = false
+	}
+	switch {
+	case !failed && (probe || b.failures < breakerThreshold):
+		b.failures = 0
+	case failed && probe:
+		b.openUntil = time.Now().Add(breakerCooldown)
+	case failed && b.failures < breakerThreshold:
+		b.failures++
+		if b.failures >= breakerThreshold {
+			b.openUntil = time.Now().Add(breakerCooldown)
+		}
+	}
+}
+
+// CheckCircuit devuelve un error si el cortocircuito está abierto. No llama a
+// OMDB ni gasta la petición de prueba, así que sirve para comprobaciones
+// frecuentes.
+func (c *Client) CheckCircuit(ctx context.Context) error {
+	c.breaker.mu.Lock()
+	defer c.breaker.mu.Unlock()
+	return c.breaker.check()
+}
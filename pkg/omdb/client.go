@@ -1,21 +1,52 @@
 package omdb
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
+	"time"
 )
 
 var (
 	// BaseURL es la URL base de la API de OMDB (exportable para pruebas)
 	BaseURL = "https://www.omdbapi.com/"
+
+	// ErrNotFound indica que OMDB no tiene la película solicitada
+	ErrNotFound = errors.New("película no encontrada")
 )
 
+// responseError convierte el campo Error de una respuesta con Response "False".
+// Solo "Movie not found!" (o "Series not found!") es ErrNotFound: el resto, como
+// "Request limit reached!" o una API key no válida, son fallos de OMDB.
+func responseError(message string) error {
+	if strings.HasSuffix(strings.ToLower(message), "not found!") {
+		return ErrNotFound
+	}
+	if message == "" {
+		message = "respuesta sin datos"
+	}
+	return fmt.Errorf("OMDB respondió con un error: %s", message)
+}
+
+// imdbIDPattern valida los IDs de IMDb: "tt" seguido de dígitos
+var imdbIDPattern = regexp.MustCompile(`^tt[0-9]{7,10}$`)
+
+// IsIMDbID indica si s tiene el formato de un ID de IMDb
+func IsIMDbID(s string) bool {
+	return imdbIDPattern.MatchString(s)
+}
+
 // Client representa un cliente para la API de OMDB
 type Client struct {
 	ApiKey     string
 	HttpClient *http.Client
+
+	breaker breaker
 }
 
 // Movie representa la estructura de datos de una película de OMDB
@@ -34,6 +65,7 @@ type Movie struct {
 	ImdbID   string `json:"imdbID"`
 	Type     string `json:"Type"`
 	Response string `json:"Response"`
+	Error    string `json:"Error,omitempty"`
 }
 
 // SearchResult representa el resultado de una búsqueda de películas
@@ -41,12 +73,14 @@ type SearchResult struct {
 	Search       []Movie `json:"Search"`
 	TotalResults string  `json:"totalResults"`
 	Response     string  `json:"Response"`
+	Error        string  `json:"Error,omitempty"`
 }
 
 // OMDBClient define la interfaz para un cliente de OMDB
 type OMDBClient interface {
-	SearchByTitle(title string) (*SearchResult, error)
-	GetMovieByTitle(title string) (*Movie, error)
+	SearchByTitle(ctx context.Context, title string) (*SearchResult, error)
+	GetMovieByTitle(ctx context.Context, title string) (*Movie, error)
+	GetMovieByID(ctx context.Context, imdbID string) (*Movie, error)
 }
 
 // NewClient crea un nuevo cliente para la API de OMDB
@@ -58,14 +92,27 @@ func NewClient(apiKey string) *Client {
 }
 
 // SearchByTitle busca películas por título
-func (c *Client) SearchByTitle(title string) (*SearchResult, error) {
+func (c *Client) SearchByTitle(ctx context.Context, title string) (_ *SearchResult, err error) {
+	start := time.Now()
+	defer func() { observeRequest(ctx, "search", start, err) }()
+	probe, err := c.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { c.breaker.record(err, probe) }()
+
 	params := url.Values{}
 	params.Add("apikey", c.ApiKey)
 	params.Add("s", title)
 
 	fullURL := fmt.Sprintf("%s?%s", BaseURL, params.Encode())
 	
-	resp, err := c.HttpClient.Get(fullURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear la solicitud HTTP: %w", err)
+	}
+
+	resp, err := c.HttpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error al hacer la solicitud HTTP: %w", err)
 	}
@@ -80,18 +127,47 @@ func (c *Client) SearchByTitle(title string) (*SearchResult, error) {
 		return nil, fmt.Errorf("error al decodificar la respuesta: %w", err)
 	}
 
+	// Una búsqueda sin coincidencias o demasiado amplia no es un fallo: se
+	// devuelve el resultado vacío
+	if result.Response == "False" && !errors.Is(responseError(result.Error), ErrNotFound) && result.Error != "Too many results." {
+		return nil, responseError(result.Error)
+	}
+
 	return &result, nil
 }
 
 // GetMovieByTitle obtiene una película por título
-func (c *Client) GetMovieByTitle(title string) (*Movie, error) {
+func (c *Client) GetMovieByTitle(ctx context.Context, title string) (*Movie, error) {
+	return c.getMovie(ctx, "get_by_title", "t", title)
+}
+
+// GetMovieByID obtiene una película por su ID de IMDb
+func (c *Client) GetMovieByID(ctx context.Context, imdbID string) (*Movie, error) {
+	return c.getMovie(ctx, "get_by_id", "i", imdbID)
+}
+
+// getMovie consulta una película usando el parámetro de búsqueda indicado
+func (c *Client) getMovie(ctx context.Context, operation, param, value string) (_ *Movie, err error) {
+	start := time.Now()
+	defer func() { observeRequest(ctx, operation, start, err) }()
+	probe, err := c.breaker.allow()
+	if err != nil {
+		return nil, err
+	}
+	defer func() { c.breaker.record(err, probe) }()
+
 	params := url.Values{}
 	params.Add("apikey", c.ApiKey)
-	params.Add("t", title)
+	params.Add(param, value)
 
 	fullURL := fmt.Sprintf("%s?%s", BaseURL, params.Encode())
 	
-	resp, err := c.HttpClient.Get(fullURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error al crear la solicitud HTTP: %w", err)
+	}
+
+	resp, err := c.HttpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error al hacer la solicitud HTTP: %w", err)
 	}
@@ -107,8 +183,30 @@ func (c *Client) GetMovieByTitle(title string) (*Movie, error) {
 	}
 
 	if movie.Response == "False" {
-		return nil, fmt.Errorf("película no encontrada")
+		return nil, responseError(movie.Error)
 	}
 
 	return &movie, nil
-} 
\ No newline at end of file
+}
+
+// Ping comprueba que la API de OMDB responde. La petición se hace sin API key,
+// por lo que OMDB la rechaza con 401 sin consumir cuota; cualquier respuesta
+// por debajo de 500 indica que el servicio está disponible.
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, BaseURL, nil)
+	if err != nil {
+		return fmt.Errorf("error al crear la solicitud HTTP: %w", err)
+	}
+
+	resp, err := c.HttpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("error al hacer la solicitud HTTP: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("status code inesperado: %d", resp.StatusCode)
+	}
+
+	return nil
+}
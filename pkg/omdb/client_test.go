@@ -1,9 +1,14 @@
 package omdb
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestNewClient(t *testing.T) {
@@ -66,7 +71,7 @@ func TestSearchByTitle(t *testing.T) {
 	defer func() { BaseURL = originalBaseURL }()
 
 	// Realizar la búsqueda
-	result, err := client.SearchByTitle("test_movie")
+	result, err := client.SearchByTitle(context.Background(), "test_movie")
 	if err != nil {
 		t.Errorf("Expected no error, got %s", err)
 	}
@@ -133,7 +138,7 @@ func TestGetMovieByTitle(t *testing.T) {
 	defer func() { BaseURL = originalBaseURL }()
 
 	// Realizar la búsqueda
-	movie, err := client.GetMovieByTitle("test_movie")
+	movie, err := client.GetMovieByTitle(context.Background(), "test_movie")
 	if err != nil {
 		t.Errorf("Expected no error, got %s", err)
 	}
@@ -153,6 +158,40 @@ func TestGetMovieByTitle(t *testing.T) {
 	}
 }
 
+func TestGetMovieByID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Verificar que se busca por ID y no por título
+		q := r.URL.Query()
+		if q.Get("i") != "tt1234567" {
+			t.Errorf("Expected i=tt1234567, got %s", q.Get("i"))
+		}
+		if q.Get("t") != "" {
+			t.Errorf("Expected no t parameter, got %s", q.Get("t"))
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Title": "Test Movie", "Year": "2023", "imdbID": "tt1234567", "Response": "True"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		ApiKey:     "test_key",
+		HttpClient: server.Client(),
+	}
+
+	originalBaseURL := BaseURL
+	BaseURL = server.URL
+	defer func() { BaseURL = originalBaseURL }()
+
+	movie, err := client.GetMovieByID(context.Background(), "tt1234567")
+	if err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if movie.ImdbID != "tt1234567" {
+		t.Errorf("Expected ImdbID=tt1234567, got %s", movie.ImdbID)
+	}
+}
+
 func TestGetMovieByTitle_Error(t *testing.T) {
 	// Crear un servidor de prueba que devuelve un error
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -177,10 +216,203 @@ func TestGetMovieByTitle_Error(t *testing.T) {
 	defer func() { BaseURL = originalBaseURL }()
 
 	// Realizar la búsqueda
-	_, err := client.GetMovieByTitle("nonexistent_movie")
+	before := requestsTotal.Value("get_by_title", "not_found")
+	_, err := client.GetMovieByTitle(context.Background(), "nonexistent_movie")
 
 	// Verificar que se retorne un error
 	if err == nil {
 		t.Error("Expected an error, got nil")
 	}
-} 
\ No newline at end of file
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %s", err)
+	}
+	if requestsTotal.Value("get_by_title", "not_found") != before+1 {
+		t.Error("Expected the not_found outcome to be counted")
+	}
+}
+
+func TestPing(t *testing.T) {
+	// Crear un servidor de prueba que rechaza las peticiones sin API key
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("apikey") != "" {
+			t.Error("Expected Ping not to send the API key")
+		}
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		ApiKey:     "test_key",
+		HttpClient: server.Client(),
+	}
+
+	originalBaseURL := BaseURL
+	BaseURL = server.URL
+	defer func() { BaseURL = originalBaseURL }()
+
+	if err := client.Ping(context.Background()); err != nil {
+		t.Errorf("Expected no error, got %s", err)
+	}
+}
+
+func TestPing_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := &Client{
+		ApiKey:     "test_key",
+		HttpClient: server.Client(),
+	}
+
+	originalBaseURL := BaseURL
+	BaseURL = server.URL
+	defer func() { BaseURL = originalBaseURL }()
+
+	if err := client.Ping(context.Background()); err == nil {
+		t.Error("Expected an error, got nil")
+	}
+}
+
+func TestClient_CircuitBreaker(t *testing.T) {
+	var calls atomic.Int32
+	failing := atomic.Bool{}
+	failing.Store(true)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"Title": "The Matrix", "Response": "True"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		ApiKey:     "test_key",
+		HttpClient: server.Client(),
+	}
+
+	originalBaseURL := BaseURL
+	BaseURL = server.URL
+	defer func() { BaseURL = originalBaseURL }()
+
+	ctx := context.Background()
+	for i := 0; i < breakerThreshold; i++ {
+		if err := client.CheckCircuit(ctx); err != nil {
+			t.Fatalf("Expected the circuit to be closed after %d failures, got %s", i, err)
+		}
+		client.GetMovieByTitle(ctx, "The Matrix")
+	}
+
+	// Abierto: no se llama a OMDB
+	if err := client.CheckCircuit(ctx); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if _, err := client.SearchByTitle(ctx, "Matrix"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != breakerThreshold {
+		t.Errorf("Expected %d calls to OMDB, got %d", breakerThreshold, calls.Load())
+	}
+
+	// Pasado el tiempo de espera, un acierto lo cierra
+	client.breaker.mu.Lock()
+	client.breaker.openUntil = time.Now()
+	client.breaker.mu.Unlock()
+	failing.Store(false)
+	if _, err := client.GetMovieByTitle(ctx, "The Matrix"); err != nil {
+		t.Fatalf("Expected no error, got %s", err)
+	}
+	if err := client.CheckCircuit(ctx); err != nil {
+		t.Errorf("Expected the circuit to be closed, got %s", err)
+	}
+}
+
+func TestClient_CircuitBreaker_NotFound(t *testing.T) {
+	var b breaker
+	for i := 0; i < 2*breakerThreshold; i++ {
+		b.record(ErrNotFound, false)
+		b.record(context.Canceled, false)
+	}
+	if _, err := b.allow(); err != nil {
+		t.Errorf("Expected not found and canceled requests not to open the circuit, got %s", err)
+	}
+}
+
+// Medio abierto: solo pasa una petición de prueba y, si falla, se vuelve a abrir
+func TestClient_CircuitBreaker_HalfOpen(t *testing.T) {
+	var b breaker
+	failure := errors.New("status code inesperado: 503")
+	for i := 0; i < breakerThreshold; i++ {
+		b.record(failure, false)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	b.openUntil = time.Now()
+	if probe, err := b.allow(); !probe || err != nil {
+		t.Fatalf("Expected a trial request, got %v %v", probe, err)
+	}
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected only one trial request, got %v", err)
+	}
+	// Un fallo de una petición anterior no cuenta ni alarga la espera
+	b.record(failure, false)
+	if !b.openUntil.Before(time.Now()) {
+		t.Error("Expected a stale failure not to reopen the circuit")
+	}
+
+	b.record(failure, true)
+	if _, err := b.allow(); !errors.Is(err, ErrCircuitOpen) || time.Until(b.openUntil) < breakerCooldown-time.Second {
+		t.Errorf("Expected a failed trial to reopen the circuit, got %v", err)
+	}
+
+	b.openUntil = time.Now()
+	probe, _ := b.allow()
+	b.record(nil, probe)
+	if probe, err := b.allow(); probe || err != nil {
+		t.Errorf("Expected a successful trial to close the circuit, got %v %v", probe, err)
+	}
+}
+
+// Test para las respuestas de error de OMDB: agotar la cuota no es ErrNotFound,
+// cuenta como fallo y una búsqueda sin resultados no es un error
+func TestClient_ResponseErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("s") == "zzzz" {
+			w.Write([]byte(`{"Response": "False", "Error": "Movie not found!"}`))
+			return
+		}
+		w.Write([]byte(`{"Response": "False", "Error": "Request limit reached!"}`))
+	}))
+	defer server.Close()
+
+	client := &Client{
+		ApiKey:     "test_key",
+		HttpClient: server.Client(),
+	}
+
+	originalBaseURL := BaseURL
+	BaseURL = server.URL
+	defer func() { BaseURL = originalBaseURL }()
+
+	ctx := context.Background()
+	for i := 0; i < breakerThreshold; i++ {
+		_, err := client.GetMovieByID(ctx, "tt0133093")
+		if err == nil || errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), "Request limit reached!") {
+			t.Fatalf("Expected a quota error, got %v", err)
+		}
+	}
+	if _, err := client.SearchByTitle(ctx, "Matrix"); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the quota errors to open the circuit, got %v", err)
+	}
+
+	client = &Client{ApiKey: "test_key", HttpClient: server.Client()}
+	result, err := client.SearchByTitle(ctx, "zzzz")
+	if err != nil || result.Response != "False" {
+		t.Errorf("Expected an empty search result, got %+v %v", result, err)
+	}
+}
@@ -0,0 +1,49 @@
+package omdb
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/prosales/go-api-movies/pkg/metrics"
+)
+
+// Métricas de las llamadas a la API de OMDB
+var (
+	requestsTotal = metrics.NewCounterVec(
+		"movies_omdb_requests_total",
+		"Llamadas a la API de OMDB por operación y resultado.",
+		"operation", "outcome",
+	)
+	requestDuration = metrics.NewHistogramVec(
+		"movies_omdb_request_duration_seconds",
+		"Latencia de las llamadas a la API de OMDB.",
+		nil,
+		"operation",
+	)
+)
+
+// observeRequest registra en métricas y en el log una llamada a OMDB iniciada en start
+func observeRequest(ctx context.Context, operation string, start time.Time, err error) {
+	outcome := "ok"
+	switch {
+	case errors.Is(err, ErrNotFound):
+		outcome = "not_found"
+	case errors.Is(err, ErrCircuitOpen):
+		outcome = "circuit_open"
+	case err != nil:
+		outcome = "error"
+	}
+
+	elapsed := time.Since(start)
+	requestsTotal.Inc(operation, outcome)
+	requestDuration.Observe(elapsed.Seconds(), operation)
+
+	attrs := []any{"operation", operation, "outcome", outcome, "duration", elapsed}
+	if outcome == "error" {
+		slog.WarnContext(ctx, "omdb request failed", append(attrs, "error", err)...)
+		return
+	}
+	slog.DebugContext(ctx, "omdb request", attrs...)
+}
@@ -0,0 +1,167 @@
+// Package peers reparte las claves de la caché entre varias réplicas. Cada
+// clave tiene un nodo dueño, elegido por hashing consistente sobre la lista de
+// nodos, y el resto de réplicas se la piden a él por HTTP.
+package peers
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Path es la ruta en la que cada nodo atiende a los demás
+const Path = "/_peers/movie"
+
+// Cabecera con el secreto compartido entre nodos
+const secretHeader = "X-Peer-Secret"
+
+// Réplicas virtuales de cada nodo en el anillo, para repartir mejor las claves
+const defaultReplicas = 64
+
+// Tamaño máximo de una respuesta de otro nodo
+const maxResponseBytes = 4 << 20
+
+// ErrNotFound indica que el dueño de la clave no encontró el valor
+var ErrNotFound = errors.New("peers: no encontrado")
+
+// Ring asigna claves a nodos con hashing consistente: al añadir o quitar un
+// nodo solo cambian de dueño las claves de ese nodo
+type Ring struct {
+	hashes []uint32
+	nodes  map[uint32]string
+}
+
+// NewRing crea un anillo con replicas puntos por nodo
+func NewRing(replicas int, nodes ...string) *Ring {
+	r := &Ring{nodes: make(map[uint32]string)}
+	for _, node := range nodes {
+		for i := 0; i < replicas; i++ {
+			h := crc32.ChecksumIEEE([]byte(strconv.Itoa(i) + node))
+			r.hashes = append(r.hashes, h)
+			r.nodes[h] = node
+		}
+	}
+	sort.Slice(r.hashes, func(i, j int) bool { return r.hashes[i] < r.hashes[j] })
+	return r
+}
+
+// Get devuelve el nodo dueño de key, o "" si el anillo está vacío
+func (r *Ring) Get(key string) string {
+	if len(r.hashes) == 0 {
+		return ""
+	}
+	h := crc32.ChecksumIEEE([]byte(key))
+	i := sort.Search(len(r.hashes), func(i int) bool { return r.hashes[i] >= h })
+	if i == len(r.hashes) {
+		i = 0
+	}
+	return r.nodes[r.hashes[i]]
+}
+
+// Pool es el conjunto de nodos visto desde uno de ellos
+type Pool struct {
+	self   string
+	ring   *Ring
+	secret string
+	client *http.Client
+}
+
+// NewPool crea el conjunto de nodos. self es la URL de este nodo tal como
+// aparece en nodes, y secret, si no está vacío, autentica las peticiones entre nodos.
+func NewPool(self string, nodes []string, secret string) *Pool {
+	self = strings.TrimSuffix(self, "/")
+	normalized := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		normalized = append(normalized, strings.TrimSuffix(node, "/"))
+	}
+	return &Pool{
+		self:   self,
+		ring:   NewRing(defaultReplicas, normalized...),
+		secret: secret,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// PickPeer devuelve el dueño de key y si es otro nodo
+func (p *Pool) PickPeer(key string) (string, bool) {
+	owner := p.ring.Get(key)
+	return owner, owner != "" && owner != p.self
+}
+
+// Fetch pide key al nodo peer. query es la consulta original, que el dueño
+// necesita si tiene que buscar el valor en el origen.
+func (p *Pool) Fetch(ctx context.Context, peer, key, query string) ([]byte, error) {
+	u := peer + Path + "?" + url.Values{"key": {key}, "q": {query}}.Encode()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if p.secret != "" {
+		req.Header.Set(secretHeader, p.secret)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return body, nil
+	case http.StatusNotFound:
+		return nil, ErrNotFound
+	default:
+		return nil, fmt.Errorf("peers: %s respondió %s: %s", peer, resp.Status, strings.TrimSpace(string(body)))
+	}
+}
+
+// LoadFunc obtiene el valor de key en el nodo dueño. Debe devolver un error que
+// envuelva ErrNotFound si el valor no existe.
+type LoadFunc func(ctx context.Context, key, query string) ([]byte, error)
+
+// Authenticated indica si la petición lleva el secreto compartido entre nodos.
+// Sin secreto ninguna petición está autenticada.
+func Authenticated(r *http.Request, secret string) bool {
+	return secret != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get(secretHeader)), []byte(secret)) == 1
+}
+
+// Handler atiende las peticiones de los demás nodos con load
+func Handler(load LoadFunc, secret string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if secret != "" && !Authenticated(r, secret) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		key := r.URL.Query().Get("key")
+		if key == "" {
+			http.Error(w, "missing key", http.StatusBadRequest)
+			return
+		}
+
+		data, err := load(r.Context(), key, r.URL.Query().Get("q"))
+		if errors.Is(err, ErrNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
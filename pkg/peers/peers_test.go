@@ -0,0 +1,105 @@
+package peers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// Test para Ring: reparto entre nodos y estabilidad al quitar uno
+func TestRing(t *testing.T) {
+	nodes := []string{"http://a", "http://b", "http://c"}
+	ring := NewRing(defaultReplicas, nodes...)
+
+	owners := make(map[string]string)
+	counts := make(map[string]int)
+	for i := 0; i < 3000; i++ {
+		key := "movie " + strconv.Itoa(i)
+		owners[key] = ring.Get(key)
+		counts[owners[key]]++
+	}
+	for _, node := range nodes {
+		if counts[node] < 500 {
+			t.Errorf("Expected keys to be spread across nodes, got %v", counts)
+			break
+		}
+	}
+
+	// Sin el nodo c, las claves de a y b no cambian de dueño
+	smaller := NewRing(defaultReplicas, "http://a", "http://b")
+	for key, owner := range owners {
+		if owner != "http://c" && smaller.Get(key) != owner {
+			t.Fatalf("Expected %q to stay on %s, got %s", key, owner, smaller.Get(key))
+		}
+	}
+
+	if got := NewRing(defaultReplicas).Get("key"); got != "" {
+		t.Errorf("Expected no owner on an empty ring, got %q", got)
+	}
+}
+
+// Test para Fetch y Handler: respuesta, no encontrado, errores y secreto
+func TestFetchAndHandler(t *testing.T) {
+	srv := httptest.NewServer(Handler(func(ctx context.Context, key, query string) ([]byte, error) {
+		switch key {
+		case "missing":
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, key)
+		case "broken":
+			return nil, errors.New("upstream down")
+		}
+		return []byte(`{"key":"` + key + `","q":"` + query + `"}`), nil
+	}, "secret"))
+	defer srv.Close()
+
+	pool := NewPool("http://self", []string{"http://self", srv.URL}, "secret")
+	ctx := context.Background()
+
+	data, err := pool.Fetch(ctx, srv.URL, "the matrix", "The Matrix")
+	if err != nil || string(data) != `{"key":"the matrix","q":"The Matrix"}` {
+		t.Errorf("Expected the loaded value, got %s, %v", data, err)
+	}
+	if _, err := pool.Fetch(ctx, srv.URL, "missing", ""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if _, err := pool.Fetch(ctx, srv.URL, "broken", ""); err == nil || errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected an upstream error, got %v", err)
+	}
+
+	other := NewPool("http://self", []string{srv.URL}, "wrong")
+	if _, err := other.Fetch(ctx, srv.URL, "the matrix", ""); err == nil {
+		t.Error("Expected a wrong secret to be rejected")
+	}
+
+	resp, err := http.Get(srv.URL + Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected status code %d without the secret, got %d", http.StatusForbidden, resp.StatusCode)
+	}
+}
+
+// Test para PickPeer: este nodo no se pide claves a sí mismo
+func TestPool_PickPeer(t *testing.T) {
+	pool := NewPool("http://a/", []string{"http://a", "http://b/"}, "")
+	remote, local := 0, 0
+	for i := 0; i < 100; i++ {
+		owner, isRemote := pool.PickPeer(strconv.Itoa(i))
+		switch {
+		case isRemote && owner == "http://b":
+			remote++
+		case !isRemote && owner == "http://a":
+			local++
+		default:
+			t.Fatalf("Unexpected owner %q (remote=%v)", owner, isRemote)
+		}
+	}
+	if remote == 0 || local == 0 {
+		t.Errorf("Expected keys on both nodes, got %d local and %d remote", local, remote)
+	}
+}
@@ -0,0 +1,115 @@
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Longitud máxima de un slug, para que las URLs sigan siendo manejables
+const maxSlugLength = 80
+
+// foldTable asocia las letras latinas acentuadas con su letra base
+var foldTable = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'Æ': "AE", 'æ': "ae",
+	'Ç': "C", 'Ć': "C", 'Ĉ': "C", 'Ċ': "C", 'Č': "C",
+	'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c", 'č': "c",
+	'Ď': "D", 'Đ': "D", 'Ð': "D", 'ď': "d", 'đ': "d", 'ð': "d",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ĕ': "E", 'Ė': "E", 'Ę': "E", 'Ě': "E",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'Ĝ': "G", 'Ğ': "G", 'Ġ': "G", 'Ģ': "G", 'ĝ': "g", 'ğ': "g", 'ġ': "g", 'ģ': "g",
+	'Ĥ': "H", 'Ħ': "H", 'ĥ': "h", 'ħ': "h",
+	'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ĩ': "I", 'Ī': "I", 'Ĭ': "I", 'Į': "I", 'İ': "I",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+	'Ĳ': "IJ", 'ĳ': "ij", 'Ĵ': "J", 'ĵ': "j", 'Ķ': "K", 'ķ': "k",
+	'Ĺ': "L", 'Ļ': "L", 'Ľ': "L", 'Ŀ': "L", 'Ł': "L", 'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+	'Ñ': "N", 'Ń': "N", 'Ņ': "N", 'Ň': "N", 'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ō': "O", 'Ŏ': "O", 'Ő': "O",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o",
+	'Œ': "OE", 'œ': "oe",
+	'Ŕ': "R", 'Ŗ': "R", 'Ř': "R", 'ŕ': "r", 'ŗ': "r", 'ř': "r",
+	'Ś': "S", 'Ŝ': "S", 'Ş': "S", 'Š': "S", 'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s", 'ß': "ss",
+	'Ţ': "T", 'Ť': "T", 'Ŧ': "T", 'ţ': "t", 'ť': "t", 'ŧ': "t", 'Þ': "TH", 'þ': "th",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ũ': "U", 'Ū': "U", 'Ŭ': "U", 'Ů': "U", 'Ű': "U", 'Ų': "U",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'Ŵ': "W", 'ŵ': "w",
+	'Ý': "Y", 'Ŷ': "Y", 'Ÿ': "Y", 'ý': "y", 'ÿ': "y", 'ŷ': "y",
+	'Ź': "Z", 'Ż': "Z", 'Ž': "Z", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// Fold elimina los acentos y diacríticos de las letras latinas. Sirve tanto para
+// texto con caracteres precompuestos ("é") como descompuestos ("e" + U+0301):
+// se descompone en NFD, se descartan las marcas que acompañan a una letra latina
+// y se recompone en NFC. Las marcas de otras escrituras se conservan, porque en
+// griego, tailandés, devanagari o kana distinguen palabras.
+func Fold(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	latin := false
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			// Marca combinante: se descarta solo si acompaña a una letra latina
+			if !latin {
+				b.WriteRune(r)
+			}
+			continue
+		}
+		latin = unicode.Is(unicode.Latin, r)
+		if base, ok := foldTable[r]; ok {
+			// Letras que no se descomponen, como "ø", "ł" o "ß"
+			b.WriteString(base)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return norm.NFC.String(b.String())
+}
+
+// Slug genera un fragmento de URL legible a partir de un título:
+// "El Laberinto del Fauno" -> "el-laberinto-del-fauno"
+func Slug(title string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(Fold(title)) {
+		// Las marcas que conserva Fold forman parte de la palabra
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+			continue
+		}
+		dash = true
+	}
+
+	slug := b.String()
+	if len(slug) > maxSlugLength {
+		slug = slug[:maxSlugLength]
+		// No cortar un carácter multibyte ni dejar un guion al final
+		for !utf8.ValidString(slug) {
+			slug = slug[:len(slug)-1]
+		}
+		slug = strings.TrimRight(slug, "-")
+	}
+	return slug
+}
+
+// Key normaliza un texto para usarlo como clave de búsqueda: sin acentos, sin
+// distinguir mayúsculas y con los espacios agrupados, de modo que "The Matrix ",
+// "THE MATRIX" y "the matrix" dan la misma clave. Como el texto se normaliza en
+// NFC, las formas compuesta y descompuesta de un carácter también coinciden.
+func Key(s string) string {
+	folded := norm.NFC.String(strings.Map(foldCase, Fold(s)))
+	return strings.Join(strings.Fields(folded), " ")
+}
+
+// foldCase pasa una letra a su forma sin mayúsculas. Pasar antes por la mayúscula
+// une las variantes que ToLower deja distintas, como la sigma final.
+func foldCase(r rune) rune {
+	return unicode.ToLower(unicode.ToUpper(r))
+}
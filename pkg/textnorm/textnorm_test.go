@@ -0,0 +1,132 @@
+package textnorm
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestFold(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Amélie", "Amelie"},
+		{"Amélie", "Amelie"},
+		{"El Laberinto del Fauno", "El Laberinto del Fauno"},
+		{"Ça ira", "Ca ira"},
+		{"Straße", "Strasse"},
+		{"Łódź", "Lodz"},
+		{"千と千尋の神隠し", "千と千尋の神隠し"},
+		// Las marcas de otras escrituras se conservan, en NFC
+		{"\u03ac", "\u03ac"},
+		{"\u03b1\u0301", "\u03ac"},
+		{"\u304b\u3099", "\u304c"},
+	}
+
+	for _, tt := range tests {
+		if got := Fold(tt.input); got != tt.expected {
+			t.Errorf("Fold(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Star Wars", "star-wars"},
+		{"Star Wars: Episode IV - A New Hope", "star-wars-episode-iv-a-new-hope"},
+		{"  Amélie  ", "amelie"},
+		{"WALL·E", "wall-e"},
+		{"2001: A Space Odyssey", "2001-a-space-odyssey"},
+		{"!!!", ""},
+		{"千と千尋の神隠し", "千と千尋の神隠し"},
+	}
+
+	for _, tt := range tests {
+		if got := Slug(tt.input); got != tt.expected {
+			t.Errorf("Slug(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+func TestSlug_MaxLength(t *testing.T) {
+	slug := Slug(strings.Repeat("ñandú ", 40))
+	if len(slug) > maxSlugLength {
+		t.Errorf("Expected slug of at most %d bytes, got %d", maxSlugLength, len(slug))
+	}
+	if strings.HasSuffix(slug, "-") {
+		t.Errorf("Expected slug not to end with a dash, got %q", slug)
+	}
+
+	long := Slug(strings.Repeat("映画", 60))
+	if !utf8.ValidString(long) {
+		t.Errorf("Expected truncated slug to be valid UTF-8, got %q", long)
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"the matrix", "the matrix"},
+		{"The Matrix ", "the matrix"},
+		{"THE  MATRIX", "the matrix"},
+		{"\tThe\nMatrix", "the matrix"},
+		{"Amélie", "amelie"},
+		{"Amélie", "amelie"},
+		{"ΟΔΥΣΣΕΙΑΣ", "οδυσσειασ"},
+		{"οδυσσειας", "οδυσσειασ"},
+		{"千と千尋の神隠し", "千と千尋の神隠し"},
+	}
+
+	for _, tt := range tests {
+		if got := Key(tt.input); got != tt.expected {
+			t.Errorf("Key(%q) = %q, expected %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// Test para Key: las formas compuesta y descompuesta dan la misma clave, y los
+// títulos que solo se distinguen por una marca de otra escritura no se mezclan
+func TestKey_Normalization(t *testing.T) {
+	pairs := []struct {
+		composed, decomposed string
+	}{
+		{"Ph\u1edf", "Pho\u031b\u0309"},
+		{"\u03ac", "\u03b1\u0301"},
+		{"\u304c", "\u304b\u3099"},
+		{"\u0915\u093c", "\u0958"},
+		{"Am\u00e9lie", "Ame\u0301lie"},
+	}
+	for _, p := range pairs {
+		if Key(p.composed) != Key(p.decomposed) {
+			t.Errorf("Expected %q and %q to have the same key, got %q and %q",
+				p.composed, p.decomposed, Key(p.composed), Key(p.decomposed))
+		}
+	}
+	if got := Key("Ph\u1edf"); got != "pho" {
+		t.Errorf("Expected Key(Phở) = %q, got %q", "pho", got)
+	}
+
+	distinct := [][2]string{
+		{"\u0e01\u0e34\u0e19", "\u0e01\u0e19"},                   // Tailandés: กิน y กน
+		{"\u0915\u093f\u0924\u093e\u092c", "\u0915\u0924\u092c"}, // Devanagari: किताब y कतब
+		{"\u304b\u3099\u304d", "\u304b\u304d"},                   // Kana: がき y かき
+		{"\u03ac\u03bb\u03bc\u03b1", "\u03b1\u03bb\u03bc\u03b1"}, // Griego: άλμα y αλμα
+	}
+	for _, d := range distinct {
+		if Key(d[0]) == Key(d[1]) {
+			t.Errorf("Expected %q and %q to have different keys, both got %q", d[0], d[1], Key(d[0]))
+		}
+	}
+}
+
+func TestSlug_NonLatin(t *testing.T) {
+	if got, want := Slug("\u0e20\u0e32\u0e1e\u0e22\u0e19\u0e15\u0e23\u0e4c"), "\u0e20\u0e32\u0e1e\u0e22\u0e19\u0e15\u0e23\u0e4c"; got != want {
+		t.Errorf("Slug(ภาพยนตร์) = %q, expected %q", got, want)
+	}
+}
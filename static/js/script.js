@@ -34,6 +34,16 @@ document.addEventListener('DOMContentLoaded', function() {
         }
     });
 
+    // Enlaces "Volver": usar el historial si existe, si no seguir el href
+    document.querySelectorAll('.js-history-back').forEach(link => {
+        link.addEventListener('click', function(e) {
+            if (window.history.length > 1) {
+                e.preventDefault();
+                window.history.back();
+            }
+        });
+    });
+
     // Activar tooltips de Bootstrap
     const tooltipTriggerList = [].slice.call(document.querySelectorAll('[data-bs-toggle="tooltip"]'));
     tooltipTriggerList.map(function (tooltipTriggerEl) {